@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/boeboe/lictl/pkg/linkedin"
+	"github.com/spf13/cobra"
+)
+
+// proxiesEnv holds extra proxy URLs, separated by commas or whitespace.
+const proxiesEnv = "LICTL_PROXIES"
+
+// newScrapeClient builds the client shared by all web calls of cmd from the
+// command line flags. ctx bounds the calls made while building it, like the
+// one fetching robots.txt.
+func newScrapeClient(ctx context.Context, cmd *cobra.Command) (*linkedin.ScrapeClient, error) {
+	opts := []linkedin.ClientOption{
+		linkedin.WithDebug(debug),
+		linkedin.WithMaxBackoff(maxBackoff),
+		linkedin.WithRetries(retries),
+		linkedin.WithTimeout(timeout),
+		linkedin.WithUserAgents(userAgents),
+	}
+	headerProfile, err := newHeaderProfile()
+	if err != nil {
+		return nil, err
+	}
+	if headerProfile != nil {
+		opts = append(opts, linkedin.WithHeaderProfile(headerProfile))
+	}
+	if len(headers) > 0 {
+		header := make(http.Header)
+		for _, h := range headers {
+			name, value, _ := linkedin.ParseHeader(h)
+			header.Add(name, value)
+		}
+		opts = append(opts, linkedin.WithHeaders(header))
+	}
+	var searchEngines []linkedin.SearchEngine
+	for _, engine := range engines {
+		searchEngines = append(searchEngines, linkedin.SearchEngines[strings.ToLower(engine)])
+	}
+	opts = append(opts, linkedin.WithSearchEngines(searchEngines...))
+	if breakerThreshold > 0 {
+		opts = append(opts, linkedin.WithCircuitBreaker(linkedin.NewCircuitBreaker(breakerThreshold, breakerCooldown)))
+	}
+	if cmd.Flags().Lookup("interval") != nil {
+		opts = append(opts, linkedin.WithRate(interval), linkedin.WithBurst(burst))
+	}
+	if cmd.Flags().Lookup("concurrency") != nil {
+		opts = append(opts, linkedin.WithConcurrency(concurrency))
+	}
+
+	cache, err := newResponseCache()
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		opts = append(opts, linkedin.WithCache(cache))
+	}
+
+	ledger, err := newRequestLedger()
+	if err != nil {
+		return nil, err
+	}
+	if ledger != nil {
+		opts = append(opts, linkedin.WithRequestLedger(ledger))
+	}
+
+	checkpoint, err := newCheckpoint(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint != nil {
+		opts = append(opts, linkedin.WithCheckpoint(checkpoint))
+	}
+
+	if cookieJarFile != "" || cookiesFile != "" {
+		jar, err := linkedin.NewCookieJar(cookieJarFile)
+		if err != nil {
+			return nil, err
+		}
+		if cookiesFile != "" {
+			if err := jar.ImportFile(cookiesFile); err != nil {
+				return nil, err
+			}
+		}
+		opts = append(opts, linkedin.WithCookieJar(jar))
+	}
+	if recordFile != "" {
+		opts = append(opts, linkedin.WithHARRecorder(linkedin.NewHARRecorder(recordFile)))
+	}
+	if replayFile != "" {
+		har, err := linkedin.LoadHAR(replayFile)
+		if err != nil {
+			return nil, err
+		}
+		replayer, err := linkedin.NewHARReplayer(har)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, linkedin.WithHARReplayer(replayer))
+	}
+
+	pool, err := newProxyPool()
+	if err != nil {
+		return nil, err
+	}
+	if pool != nil {
+		opts = append(opts, linkedin.WithProxyPool(pool))
+	}
+
+	client := linkedin.NewScrapeClient(opts...)
+	if err := setRobotsPolicy(ctx, client); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// closeScrapeClient closes client, which writes its --record file. Commands
+// defer it, so the file is written on Ctrl-C as well, as that cancels the
+// command context instead of exiting.
+func closeScrapeClient(client *linkedin.ScrapeClient) {
+	if err := client.Close(); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// setRobotsPolicy sets the robots.txt policy of the --robots flag on client.
+// The site's robots.txt is fetched through client itself.
+func setRobotsPolicy(ctx context.Context, client *linkedin.ScrapeClient) error {
+	var policy *linkedin.RobotsPolicy
+	var err error
+	switch robots {
+	case "":
+		return nil
+	case "bundled":
+		policy, err = linkedin.BundledRobotsPolicy(robotsAgent)
+	case "site":
+		policy, err = linkedin.FetchRobotsPolicyContext(ctx, client, robotsAgent)
+	default:
+		policy, err = linkedin.LoadRobotsPolicy(robots, robotsAgent)
+	}
+	if err != nil {
+		return err
+	}
+	client.SetRobotsPolicy(policy)
+	return nil
+}
+
+// newProxyPool combines the proxies of the --proxies and --proxy-file flags
+// and the LICTL_PROXIES environment variable into one pool. It returns nil
+// when no proxies are configured.
+func newProxyPool() (*linkedin.ProxyPool, error) {
+	all := append([]string{}, proxies...)
+	if proxyFile != "" {
+		fileProxies, err := linkedin.LoadProxyFile(proxyFile)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, fileProxies...)
+	}
+	all = append(all, linkedin.ParseProxyList(os.Getenv(proxiesEnv))...)
+	if len(all) == 0 {
+		return nil, nil
+	}
+
+	strategy, _ := linkedin.SetProxyStrategy(proxyStrategy)
+	return linkedin.NewProxyPool(all, strategy)
+}
+
+// newHeaderProfile looks up the profile of the --profile flag among the
+// built-in profiles and those of the --profile-file flag. It returns nil when
+// no profile is selected, leaving the choice to the client. A profile file
+// with a single profile selects that profile.
+func newHeaderProfile() (*linkedin.HeaderProfile, error) {
+	profiles := make(map[string]*linkedin.HeaderProfile)
+	for name, p := range linkedin.DefaultHeaderProfiles {
+		profiles[name] = p
+	}
+	if profileFile != "" {
+		fileProfiles, err := linkedin.LoadHeaderProfiles(profileFile)
+		if err != nil {
+			return nil, err
+		}
+		if profile == "" && len(fileProfiles) == 1 {
+			return fileProfiles[0], nil
+		}
+		for _, p := range fileProfiles {
+			profiles[strings.ToLower(p.Name)] = p
+		}
+	}
+
+	if profile == "" {
+		return nil, nil
+	}
+	p, ok := profiles[strings.ToLower(profile)]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile: %s", profile)
+	}
+	return p, nil
+}
+
+// newRequestLedger creates the ledger of the --ledger flag with the budgets
+// of the --daily-budget and --hourly-budget flags. Without --ledger, the
+// ledger is kept in the user cache folder, so every run on the machine shares
+// it. It returns nil when no budget is configured.
+func newRequestLedger() (*linkedin.RequestLedger, error) {
+	if len(dailyBudgets) == 0 && len(hourlyBudgets) == 0 {
+		return nil, nil
+	}
+
+	path := ledgerFile
+	if path == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the user cache folder, use --ledger instead: %w", err)
+		}
+		dir = filepath.Join(dir, "lictl")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create ledger folder: %w", err)
+		}
+		path = filepath.Join(dir, "ledger.json")
+	}
+
+	ledger := linkedin.NewRequestLedger(path)
+	for _, budget := range dailyBudgets {
+		host, limit, _ := linkedin.ParseBudget(budget)
+		ledger.SetDailyBudget(host, limit)
+	}
+	for _, budget := range hourlyBudgets {
+		host, limit, _ := linkedin.ParseBudget(budget)
+		ledger.SetHourlyBudget(host, limit)
+	}
+	return ledger, nil
+}
+
+// checkpointTaskFlags are the flags besides --regions and --keywords that
+// identify the task of a checkpoint.
+var checkpointTaskFlags = []string{
+	"posted", "level", "job-type", "on-site", "remote", "hybrid", "company-id", "geo-id",
+	"distance", "easy-apply", "under-10-applicants", "sort", "max-results", "max-pages",
+}
+
+// newCheckpoint opens the checkpoint of the --checkpoint flag for the run of
+// cmd, which is identified by the command, its search terms, filters and
+// paging. It returns nil when no checkpoint is configured.
+func newCheckpoint(cmd *cobra.Command) (*linkedin.Checkpoint, error) {
+	if cmd.Flags().Lookup("checkpoint") == nil || checkpointFile == "" {
+		return nil, nil
+	}
+
+	task := cmd.CommandPath()
+	for _, name := range []string{"regions", "keywords"} {
+		if flag := cmd.Flags().Lookup(name); flag != nil {
+			task += fmt.Sprintf(" --%s=%s", name, flag.Value.String())
+		}
+	}
+	// The filters and paging change which results a run finds, so a run with
+	// other ones must not resume the checkpoint. Flags left at their default
+	// are left out, which keeps checkpoints written without them valid.
+	for _, name := range checkpointTaskFlags {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			task += fmt.Sprintf(" --%s=%s", name, flag.Value.String())
+		}
+	}
+	return linkedin.OpenCheckpoint(checkpointFile, task)
+}
+
+// newResponseCache creates the cache of the --cache-dir flag with the
+// lifetimes of the --cache-ttl flag. It returns nil when no cache is
+// configured.
+func newResponseCache() (*linkedin.ResponseCache, error) {
+	if cacheDir == "" {
+		return nil, nil
+	}
+
+	cache, err := linkedin.NewResponseCache(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	for entityType, ttl := range cacheTTLs {
+		parsedType, _ := linkedin.SetEntityType(entityType)
+		parsedTTL, _ := time.ParseDuration(ttl)
+		cache.SetTTL(parsedType, parsedTTL)
+	}
+	cache.SetOffline(offline)
+	return cache, nil
+}
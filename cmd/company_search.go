@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
 
 	"github.com/boeboe/lictl/pkg/linkedin"
 	"github.com/spf13/cobra"
@@ -17,37 +16,29 @@ var companySearchCmd = &cobra.Command{
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 
-		// Fetching companies
-		companies, err := linkedin.SearchCompaniesOnline(keywords, interval, debug)
+		ctx, cancel := newCommandContext()
+		defer cancel()
+
+		client, err := newScrapeClient(ctx, cmd)
 		if err != nil {
-			if httpErr, ok := err.(*linkedin.HTTPError); ok && httpErr.StatusCode == http.StatusTooManyRequests {
-				fmt.Println("Warning: You've hit the rate limit (HTTP 429 Too Many Requests). Please avoid making further requests for some time.")
-			} else {
-				fmt.Println("Error:", err)
-			}
+			fmt.Println("Error:", err)
 			return
 		}
+		defer closeScrapeClient(client)
 
-		// Writing companies to output file
-		var outErr error
-		var filePath string
-		format, _ := linkedin.SetFormat(formatString)
-
-		switch format {
-		case linkedin.JSON:
-			filePath, outErr = writeOutput(linkedin.ConvertToJSON(companies), outputDir, "companies", "json")
-		case linkedin.CSV:
-			filePath, outErr = writeOutput(linkedin.ConvertToCSV(companies), outputDir, "companies", "json")
-		}
-
-		if outErr != nil {
-			fmt.Println("Error writing companies:", outErr)
-			fmt.Println("Falling back to printing companies:")
-			fmt.Printf("Companies: %+v\n", companies)
+		if hitsOnly {
+			// Writing the search engine hits only, leaving the companies to the get command
+			writeHits(client, func() (linkedin.SearchHits, error) {
+				return linkedin.SearchCompanyHitsOnlineContext(ctx, client, keywords, debug)
+			})
 			return
 		}
 
-		fmt.Printf("Companies written to file %s\n", filePath)
+		// Fetching companies and writing them to the output file as they come in
+		writeRecords(client, "companies", func(yield func(*linkedin.Company, error) bool) error {
+			return linkedin.StreamCompaniesOnlineContext(ctx, client, keywords, debug, yield)
+		})
+		printSkipped(client)
 	},
 }
 
@@ -55,4 +46,7 @@ func init() {
 	companyCmd.AddCommand(companySearchCmd)
 	addRequiredKeywordsFlag(companySearchCmd)
 	addIntervalFlag(companySearchCmd)
+	addCheckpointFlag(companySearchCmd)
+	addHitsOnlyFlag(companySearchCmd)
+	addConcurrencyFlag(companySearchCmd)
 }
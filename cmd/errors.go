@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/boeboe/lictl/pkg/linkedin"
+)
+
+// printFetchError prints err along with guidance on how to deal with it.
+func printFetchError(err error) {
+	switch {
+	case errors.Is(err, linkedin.ErrCircuitOpen):
+		fmt.Println("Warning: LinkedIn kept blocking requests, so the remaining pages were skipped. Please avoid making further requests for some time.")
+	case errors.Is(err, linkedin.ErrBudgetExhausted):
+		fmt.Println("Warning: The request budget ran out, so the remaining pages were skipped. Wait for the budget to renew, or raise --daily-budget or --hourly-budget.")
+	case errors.Is(err, linkedin.ErrRateLimited):
+		fmt.Println("Warning: You've hit the rate limit (HTTP 429 Too Many Requests). Please avoid making further requests for some time.")
+		var httpErr *linkedin.HTTPError
+		if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+			fmt.Printf("LinkedIn asked to retry after %v.\n", httpErr.RetryAfter)
+		}
+	case errors.Is(err, linkedin.ErrAuthWall):
+		fmt.Println("Warning: LinkedIn served its sign in page instead of the requested page. Increase --interval or switch --proxies before trying again.")
+	case errors.Is(err, linkedin.ErrCaptcha):
+		fmt.Println("Warning: A captcha challenge was served instead of the requested page. Wait before trying again, or switch --proxies.")
+	case errors.Is(err, linkedin.ErrNotFound):
+		fmt.Println("Error: The requested page does not exist. Please check the url.")
+	case errors.Is(err, linkedin.ErrCacheMiss):
+		fmt.Println("Error: The page is not in the cache. Run the command once without --offline to fill the cache.")
+	case errors.Is(err, linkedin.ErrDisallowed):
+		fmt.Println("Error: robots.txt does not allow fetching the requested page. Run without --robots to ignore robots.txt.")
+	case errors.Is(err, linkedin.ErrNotRecorded):
+		fmt.Println("Error: The request is not in the replayed HAR file. Record the session again with --record.")
+	case errors.Is(err, linkedin.ErrEmptyParse):
+		fmt.Println("Warning: The page was fetched, but no details could be extracted from it. LinkedIn may have changed its page layout.")
+	}
+	fmt.Println("Error:", err)
+}
+
+// isStoppedEarly reports whether err was caused by a signal, an expired
+// deadline, the circuit breaker or a request budget, in which case partial
+// results are still worth writing.
+func isStoppedEarly(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, linkedin.ErrCircuitOpen) || errors.Is(err, linkedin.ErrBudgetExhausted)
+}
+
+// printSkipped prints the URLs client skipped because robots.txt disallows
+// them.
+func printSkipped(client *linkedin.ScrapeClient) {
+	disallowed := client.Disallowed()
+	if len(disallowed) == 0 {
+		return
+	}
+	fmt.Printf("Skipped %d URLs disallowed by robots.txt.\n", len(disallowed))
+	if debug {
+		for _, url := range disallowed {
+			fmt.Println("  " + url)
+		}
+	}
+}
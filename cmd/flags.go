@@ -7,39 +7,189 @@ import (
 	"fmt"
 	"log"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 
-	"github.com/boeboe/licli/pkg/linkedin"
+	"github.com/boeboe/lictl/pkg/linkedin"
 	"github.com/spf13/cobra"
 )
 
 var (
-	debug        bool
-	formatString string
-	interval     time.Duration
-	keywords     []string
-	outputDir    string
-	urlString    string
+	breakerCooldown  time.Duration
+	breakerThreshold int
+	burst            int
+	cacheDir         string
+	cacheTTLs        map[string]string
+	checkpointFile   string
+	companyIDs       []string
+	concurrency      int
+	cookieJarFile    string
+	cookiesFile      string
+	dailyBudgets     []string
+	deadline         time.Duration
+	debug            bool
+	distance         int
+	easyApply        bool
+	engines          []string
+	fewApplicants    bool
+	formatString     string
+	geoID            string
+	headers          []string
+	hitsOnly         bool
+	hourlyBudgets    []string
+	hybrid           bool
+	interval         time.Duration
+	jobTypes         []string
+	keywords         []string
+	ledgerFile       string
+	levels           []string
+	maxBackoff       time.Duration
+	maxPages         int
+	maxResults       int
+	offline          bool
+	onSite           bool
+	outputDir        string
+	postedWithin     string
+	profile          string
+	profileFile      string
+	proxies          []string
+	proxyFile        string
+	proxyStrategy    string
+	recordFile       string
+	remote           bool
+	replayFile       string
+	retries          int
+	robots           string
+	robotsAgent      string
+	sortBy           string
+	timeout          time.Duration
+	urlString        string
+	userAgents       []string
 )
 
 func addPersistentFlags(cmd *cobra.Command) {
-	cmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable or disable debug mode")
-	cmd.Flags().StringVarP(&formatString, "format", "f", "json", "Output format")
-	cmd.Flags().StringVarP(&outputDir, "output", "o", "", "Output folder (default is current folder)")
+	cmd.PersistentFlags().IntVar(&breakerThreshold, "breaker-threshold", 3, "Stop after this many consecutive blocked requests (0 disables the circuit breaker)")
+	cmd.PersistentFlags().DurationVar(&breakerCooldown, "breaker-cooldown", 0, "Pause this long once the circuit breaker opens instead of stopping (default is stopping)")
+	cmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", "", "Folder to cache fetched pages in (default is no cache)")
+	cmd.PersistentFlags().StringToStringVar(&cacheTTLs, "cache-ttl", nil, "Cache lifetime per entity type, e.g. job=1h,company=720h")
+	cmd.PersistentFlags().StringVar(&cookieJarFile, "cookie-jar", "", "File to keep session cookies in between runs")
+	cmd.PersistentFlags().StringVar(&cookiesFile, "cookies", "", "Netscape cookies.txt or JSON cookie export to import")
+	cmd.PersistentFlags().StringSliceVar(&dailyBudgets, "daily-budget", nil, "Maximum number of web calls per day, per host as host=limit or for every host as limit, shared by all runs using the same ledger")
+	cmd.PersistentFlags().DurationVar(&deadline, "deadline", 0, "Maximum duration of the command (default is no deadline)")
+	cmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "Enable or disable debug mode")
+	cmd.PersistentFlags().StringSliceVar(&engines, "engine", []string{"google"}, "One or more search engines to find LinkedIn URLs with (google, bing or duckduckgo), falling back to the next one when an engine fails or finds nothing")
+	cmd.PersistentFlags().StringVarP(&formatString, "format", "f", "json", "Output format")
+	cmd.PersistentFlags().StringArrayVar(&headers, "header", nil, "Extra \"Name: value\" header sent with every web call, overriding the profile (can be repeated)")
+	cmd.PersistentFlags().StringSliceVar(&hourlyBudgets, "hourly-budget", nil, "Maximum number of web calls per hour, like --daily-budget")
+	cmd.PersistentFlags().StringVar(&ledgerFile, "ledger", "", "File counting web calls across runs for the budgets (default is ledger.json in the user cache folder)")
+	cmd.PersistentFlags().DurationVar(&maxBackoff, "max-backoff", 30*time.Second, "Maximum wait between two attempts of a web call")
+	cmd.PersistentFlags().BoolVar(&offline, "offline", false, "Only serve pages from the cache, never fetching them")
+	cmd.PersistentFlags().StringVarP(&outputDir, "output", "o", "", "Output folder (default is current folder)")
+	cmd.PersistentFlags().StringVar(&profile, "profile", "", "Browser header profile (chrome, firefox, safari-mobile or one from --profile-file, default is a random built-in profile)")
+	cmd.PersistentFlags().StringVar(&profileFile, "profile-file", "", "JSON file with extra header profiles")
+	cmd.PersistentFlags().StringSliceVar(&proxies, "proxies", nil, "One or more proxy URLs to rotate through (also read from $LICTL_PROXIES)")
+	cmd.PersistentFlags().StringVar(&proxyFile, "proxy-file", "", "File with one proxy URL per line")
+	cmd.PersistentFlags().StringVar(&proxyStrategy, "proxy-strategy", "round-robin", "Proxy selection strategy (round-robin, random or sticky)")
+	cmd.PersistentFlags().StringVar(&recordFile, "record", "", "Record every web call into a HAR file")
+	cmd.PersistentFlags().StringVar(&replayFile, "replay", "", "Serve every web call from a HAR file instead of the network")
+	cmd.PersistentFlags().StringVar(&robots, "robots", "", "Honour robots.txt, read from \"bundled\", \"site\" or a local file (default is ignoring robots.txt)")
+	cmd.PersistentFlags().StringVar(&robotsAgent, "robots-agent", linkedin.DefaultRobotsAgent, "User agent to match robots.txt rules against")
+	cmd.PersistentFlags().IntVar(&retries, "retries", 3, "Maximum number of attempts per web call, retrying transport errors, 429 and 5xx responses")
+	cmd.PersistentFlags().DurationVar(&timeout, "timeout", 30*time.Second, "Timeout of a single web call")
+	cmd.PersistentFlags().StringSliceVar(&userAgents, "user-agents", nil, "One or more user agents to rotate through (default is random)")
 }
 
 func addIntervalFlag(cmd *cobra.Command) {
-	cmd.Flags().DurationVarP(&interval, "interval", "i", 100*time.Millisecond, "Interval between web calls")
+	cmd.Flags().DurationVarP(&interval, "interval", "i", 100*time.Millisecond, "Interval between web calls to the same host")
+	cmd.Flags().IntVar(&burst, "burst", 1, "Number of web calls to the same host allowed back to back")
+}
+
+func addConcurrencyFlag(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&concurrency, "concurrency", 1, "Number of pages fetched at once")
+}
+
+func addCheckpointFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&checkpointFile, "checkpoint", "", "File to record progress in, resuming the run recorded there")
+}
+
+func addHitsOnlyFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&hitsOnly, "hits-only", false, "Only write the search engine hits (URL, title and snippet), without fetching the pages")
+}
+
+func addJobFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&postedWithin, "posted", "", "Only jobs posted within 24h, week or month, overriding posted: in the keywords")
+	cmd.Flags().StringSliceVar(&levels, "level", nil, "One or more experience levels (internship, entry, associate, senior, director or executive)")
+	cmd.Flags().StringSliceVar(&jobTypes, "job-type", nil, "One or more job types (full-time, part-time, contract, temporary, volunteer, internship or other)")
+	cmd.Flags().BoolVar(&onSite, "on-site", false, "Only on-site jobs, combined with --remote and --hybrid as any of them")
+	cmd.Flags().BoolVar(&remote, "remote", false, "Only remote jobs, combined with --on-site and --hybrid as any of them")
+	cmd.Flags().BoolVar(&hybrid, "hybrid", false, "Only hybrid jobs, combined with --on-site and --remote as any of them")
+	cmd.Flags().StringSliceVar(&companyIDs, "company-id", nil, "One or more numeric LinkedIn company IDs to only find jobs of")
+	cmd.Flags().StringVar(&geoID, "geo-id", "", "Numeric LinkedIn geo ID of the location, more precise than the region names")
+	cmd.Flags().IntVar(&distance, "distance", 0, "Radius around the location in miles (default is the LinkedIn default)")
+	cmd.Flags().BoolVar(&easyApply, "easy-apply", false, "Only jobs with Easy Apply")
+	cmd.Flags().BoolVar(&fewApplicants, "under-10-applicants", false, "Only jobs with fewer than 10 applicants")
+	cmd.Flags().StringVar(&sortBy, "sort", "", "Sort order of the jobs (relevance or date, default is the LinkedIn default)")
+}
+
+func addPagingFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&maxResults, "max-results", 0, "Maximum number of jobs to find (default is every job)")
+	cmd.Flags().IntVar(&maxPages, "max-pages", 0, "Maximum number of result pages to fetch per query (default is every page)")
 }
 
 func addRequiredKeywordsFlag(cmd *cobra.Command) {
-	cmd.Flags().StringSliceVarP(&keywords, "keywords", "k", nil, "One or more keywords")
+	cmd.Flags().VarP((*keywordsValue)(&keywords), "keywords", "k", "One or more keywords or queries, separated by commas, e.g. '\"site reliability\" AND (Go OR Rust) -intern' (can be repeated)")
 	if err := cmd.MarkFlagRequired("keywords"); err != nil {
 		log.Fatalf("Error marking keywords flag as required: %v", err)
 	}
 }
 
+// keywordsValue is the value of the keywords flag. Like a string slice, it
+// splits its values on commas, but not on those inside a quoted phrase or
+// parentheses, which the CSV parsing of a string slice would mangle.
+type keywordsValue []string
+
+func (v *keywordsValue) Set(s string) error {
+	*v = append(*v, splitKeywords(s)...)
+	return nil
+}
+
+func (v *keywordsValue) String() string {
+	return "[" + strings.Join(*v, ",") + "]"
+}
+
+func (v *keywordsValue) Type() string {
+	return "strings"
+}
+
+// splitKeywords splits s on the commas outside quoted phrases and
+// parentheses, dropping empty queries.
+func splitKeywords(s string) []string {
+	var queries []string
+	add := func(query string) {
+		if query = strings.TrimSpace(query); query != "" {
+			queries = append(queries, query)
+		}
+	}
+	depth, quoted, start := 0, false, 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case r == ',' && depth == 0:
+			add(s[start:i])
+			start = i + 1
+		}
+	}
+	add(s[start:])
+	return queries
+}
+
 func addRequiredRegionsFlag(cmd *cobra.Command) {
 	cmd.Flags().StringSliceVarP(&regions, "regions", "r", nil, "One or more regions")
 	if err := cmd.MarkFlagRequired("regions"); err != nil {
@@ -83,18 +233,270 @@ func ValidateIntervalFlag() error {
 	if interval <= 0 {
 		return errors.New("interval should be larger then 0")
 	}
+	if burst <= 0 {
+		return errors.New("burst should be larger then 0")
+	}
+
+	return nil
+}
+
+func ValidateConcurrencyFlag() error {
+	if concurrency <= 0 {
+		return errors.New("concurrency should be larger then 0")
+	}
+
+	return nil
+}
+
+func ValidateDeadlineFlag() error {
+	if deadline < 0 {
+		return errors.New("deadline should not be negative")
+	}
+
+	return nil
+}
+
+func ValidateClientFlags() error {
+	if retries <= 0 {
+		return errors.New("retries should be larger then 0")
+	}
+	if timeout <= 0 {
+		return errors.New("timeout should be larger then 0")
+	}
+	if breakerThreshold < 0 {
+		return errors.New("breaker-threshold should not be negative")
+	}
+	if breakerCooldown < 0 {
+		return errors.New("breaker-cooldown should not be negative")
+	}
+	if maxBackoff <= 0 {
+		return errors.New("max-backoff should be larger then 0")
+	}
+	if _, err := linkedin.SetProxyStrategy(proxyStrategy); err != nil {
+		return fmt.Errorf("invalid proxy strategy. Valid strategies are: %s, %s, %s", "round-robin", "random", "sticky")
+	}
+
+	return nil
+}
+
+func ValidateCacheFlags() error {
+	if offline && cacheDir == "" {
+		return errors.New("offline requires a cache-dir")
+	}
+	for entityType, ttl := range cacheTTLs {
+		if _, err := linkedin.SetEntityType(entityType); err != nil {
+			return fmt.Errorf("invalid cache-ttl entity type %s. Valid types are: job, company, post, pulse, user, search, other", entityType)
+		}
+		if d, err := time.ParseDuration(ttl); err != nil || d < 0 {
+			return fmt.Errorf("invalid cache-ttl duration for %s: %s", entityType, ttl)
+		}
+	}
+
+	return nil
+}
+
+func ValidateHARFlags() error {
+	if recordFile != "" && replayFile != "" {
+		return errors.New("record and replay cannot be used together")
+	}
+	if replayFile != "" {
+		if _, err := os.Stat(replayFile); err != nil {
+			return fmt.Errorf("replay file does not exist: %s", replayFile)
+		}
+	}
+
+	return nil
+}
+
+func ValidateRobotsFlags() error {
+	switch robots {
+	case "", "bundled", "site":
+	default:
+		if _, err := os.Stat(robots); err != nil {
+			return fmt.Errorf("robots should be bundled, site or an existing file: %s", robots)
+		}
+	}
+	if robotsAgent == "" {
+		return errors.New("robots-agent cannot be empty")
+	}
 
 	return nil
 }
 
+func ValidateHeaderFlags() error {
+	for _, header := range headers {
+		if _, _, err := linkedin.ParseHeader(header); err != nil {
+			return err
+		}
+	}
+	if profileFile == "" && profile != "" {
+		if _, ok := linkedin.DefaultHeaderProfiles[strings.ToLower(profile)]; !ok {
+			return fmt.Errorf("invalid profile. Valid profiles are: %s", strings.Join(linkedin.HeaderProfileNames(), ", "))
+		}
+	}
+
+	return nil
+}
+
+func ValidateKeywordsFlag() error {
+	if _, err := linkedin.ParseKeywords(keywords); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// newJobFilters returns the job search filters of the flags.
+func newJobFilters() (linkedin.JobFilters, error) {
+	filters := linkedin.JobFilters{
+		CompanyIDs:    companyIDs,
+		GeoID:         geoID,
+		Distance:      distance,
+		EasyApply:     easyApply,
+		FewApplicants: fewApplicants,
+	}
+	if postedWithin != "" {
+		posted, err := linkedin.SetPostedWithin(postedWithin)
+		if err != nil {
+			return filters, errors.New("invalid posted. Valid values are: 24h, week, month, any")
+		}
+		filters.Posted = posted
+	}
+	for _, name := range levels {
+		level, err := linkedin.SetExperienceLevel(name)
+		if err != nil {
+			return filters, errors.New("invalid level. Valid levels are: internship, entry, associate, senior, director, executive")
+		}
+		filters.Levels = append(filters.Levels, level)
+	}
+	for _, name := range jobTypes {
+		jobType, err := linkedin.SetJobType(name)
+		if err != nil {
+			return filters, errors.New("invalid job type. Valid job types are: full-time, part-time, contract, temporary, volunteer, internship, other")
+		}
+		filters.JobTypes = append(filters.JobTypes, jobType)
+	}
+	if onSite {
+		filters.Workplaces = append(filters.Workplaces, linkedin.WorkplaceOnSite)
+	}
+	if remote {
+		filters.Workplaces = append(filters.Workplaces, linkedin.WorkplaceRemote)
+	}
+	if hybrid {
+		filters.Workplaces = append(filters.Workplaces, linkedin.WorkplaceHybrid)
+	}
+	if sortBy != "" {
+		sort, err := linkedin.SetJobSort(sortBy)
+		if err != nil {
+			return filters, errors.New("invalid sort. Valid sort orders are: relevance, date")
+		}
+		filters.Sort = sort
+	}
+	return filters, filters.Validate()
+}
+
+func ValidateJobFilterFlags() error {
+	_, err := newJobFilters()
+	return err
+}
+
+func ValidatePagingFlags() error {
+	if maxResults < 0 {
+		return errors.New("max-results should not be negative")
+	}
+	if maxPages < 0 {
+		return errors.New("max-pages should not be negative")
+	}
+
+	return nil
+}
+
+func ValidateEngineFlag() error {
+	if len(engines) == 0 {
+		return errors.New("engine should name at least one search engine")
+	}
+	for _, engine := range engines {
+		if _, ok := linkedin.SearchEngines[strings.ToLower(engine)]; !ok {
+			return fmt.Errorf("invalid engine. Valid engines are: %s", strings.Join(linkedin.SearchEngineNames(), ", "))
+		}
+	}
+
+	return nil
+}
+
+func ValidateBudgetFlags() error {
+	for _, budget := range append(append([]string{}, dailyBudgets...), hourlyBudgets...) {
+		if _, _, err := linkedin.ParseBudget(budget); err != nil {
+			return err
+		}
+	}
+	if ledgerFile != "" && len(dailyBudgets) == 0 && len(hourlyBudgets) == 0 {
+		return errors.New("ledger should be used with daily-budget or hourly-budget")
+	}
+
+	return nil
+}
+
+// ValidateFlags validates the flags that are registered on cmd.
 func ValidateFlags(cmd *cobra.Command, args []string) error {
 	if err := ValidateFormatFlag(); err != nil {
 		return err
 	}
-	if err := ValidateUrlFlag(); err != nil {
+	if cmd.Flags().Lookup("url") != nil {
+		if err := ValidateUrlFlag(); err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Lookup("hits-only") != nil && hitsOnly && checkpointFile != "" {
+		return errors.New("checkpoint can not be used with hits-only")
+	}
+	if cmd.Flags().Lookup("keywords") != nil {
+		if err := ValidateKeywordsFlag(); err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Lookup("posted") != nil {
+		if err := ValidateJobFilterFlags(); err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Lookup("max-results") != nil {
+		if err := ValidatePagingFlags(); err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Lookup("interval") != nil {
+		if err := ValidateIntervalFlag(); err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Lookup("concurrency") != nil {
+		if err := ValidateConcurrencyFlag(); err != nil {
+			return err
+		}
+	}
+	if err := ValidateDeadlineFlag(); err != nil {
+		return err
+	}
+	if err := ValidateClientFlags(); err != nil {
+		return err
+	}
+	if err := ValidateCacheFlags(); err != nil {
+		return err
+	}
+	if err := ValidateHARFlags(); err != nil {
+		return err
+	}
+	if err := ValidateRobotsFlags(); err != nil {
+		return err
+	}
+	if err := ValidateHeaderFlags(); err != nil {
+		return err
+	}
+	if err := ValidateBudgetFlags(); err != nil {
 		return err
 	}
-	if err := ValidateIntervalFlag(); err != nil {
+	if err := ValidateEngineFlag(); err != nil {
 		return err
 	}
 	return nil
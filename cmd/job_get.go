@@ -16,17 +16,22 @@ var jobGetCmd = &cobra.Command{
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 
+		ctx, cancel := newCommandContext()
+		defer cancel()
+
+		client, err := newScrapeClient(ctx, cmd)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		defer closeScrapeClient(client)
+
 		// Fetching job details
-		// job, err := linkedin.GetJobFromUrl(urlString, debug)
-		job := linkedin.Job{}
-		// if err != nil {
-		//   if httpErr, ok := err.(*linkedin.HTTPError); ok && httpErr.StatusCode == http.StatusTooManyRequests {
-		//     fmt.Println("Warning: You've hit the rate limit (HTTP 429 Too Many Requests). Please avoid making further requests for some time.")
-		//   } else {
-		//     fmt.Println("Error:", err)
-		//   }
-		//   return
-		// }
+		job, err := linkedin.GetJobDetailFromUrlContext(ctx, client, urlString, debug)
+		if err != nil {
+			printFetchError(err)
+			return
+		}
 
 		// Writing job details to output file
 		var outErr error
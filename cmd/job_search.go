@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
 
 	"github.com/boeboe/lictl/pkg/linkedin"
 	"github.com/spf13/cobra"
@@ -19,37 +18,35 @@ var jobSearchCmd = &cobra.Command{
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 
-		// Fetching jobs
-		jobs, err := linkedin.SearchJobsOnline(regions, keywords, interval, debug)
+		ctx, cancel := newCommandContext()
+		defer cancel()
+
+		client, err := newScrapeClient(ctx, cmd)
 		if err != nil {
-			if httpErr, ok := err.(*linkedin.HTTPError); ok && httpErr.StatusCode == http.StatusTooManyRequests {
-				fmt.Println("Warning: You've hit the rate limit (HTTP 429 Too Many Requests). Please avoid making further requests for some time.")
-			} else {
-				fmt.Println("Error:", err)
-			}
+			fmt.Println("Error:", err)
 			return
 		}
+		defer closeScrapeClient(client)
 
-		// Writing jobs to output file
-		var outErr error
-		var filePath string
-		format, _ := linkedin.SetFormat(formatString)
-
-		switch format {
-		case linkedin.JSON:
-			filePath, outErr = writeOutput(linkedin.ConvertToJSON(jobs), outputDir, "jobs", "json")
-		case linkedin.CSV:
-			filePath, outErr = writeOutput(linkedin.ConvertToCSV(jobs), outputDir, "jobs", "json")
-		}
-
-		if outErr != nil {
-			fmt.Println("Error writing jobs:", outErr)
-			fmt.Println("Falling back to printing jobs:")
-			fmt.Printf("Jobs: %+v\n", jobs)
+		filters, err := newJobFilters()
+		if err != nil {
+			fmt.Println("Error:", err)
 			return
 		}
 
-		fmt.Printf("Jobs written to file %s\n", filePath)
+		// Fetching jobs and writing them to the output file as they come in
+		var counts []linkedin.JobQueryCount
+		writeRecords(client, "jobs", func(yield func(*linkedin.Job, error) bool) error {
+			return linkedin.StreamJobsOnlineContext(ctx, client, regions, keywords, debug, yield,
+				linkedin.WithJobFilters(filters),
+				linkedin.WithMaxResults(maxResults),
+				linkedin.WithMaxPages(maxPages),
+				linkedin.WithQueryCounts(func(count linkedin.JobQueryCount) {
+					counts = append(counts, count)
+				}))
+		})
+		printQueryCounts(counts)
+		printSkipped(client)
 	},
 }
 
@@ -57,5 +54,21 @@ func init() {
 	jobCmd.AddCommand(jobSearchCmd)
 	addRequiredKeywordsFlag(jobSearchCmd)
 	addRequiredRegionsFlag(jobSearchCmd)
+	addJobFilterFlags(jobSearchCmd)
+	addPagingFlags(jobSearchCmd)
 	addIntervalFlag(jobSearchCmd)
+	addCheckpointFlag(jobSearchCmd)
+}
+
+// printQueryCounts prints the number of jobs every query found, out of the
+// number of results LinkedIn reported, and how many of them earlier queries
+// did not.
+func printQueryCounts(counts []linkedin.JobQueryCount) {
+	for _, count := range counts {
+		if count.Total > 0 {
+			fmt.Printf("Query %q found %d of %d jobs, %d new.\n", count.Query.String(), count.Found, count.Total, count.New)
+			continue
+		}
+		fmt.Printf("Query %q found %d jobs, %d new.\n", count.Query.String(), count.Found, count.New)
+	}
 }
@@ -1,12 +1,39 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
+
+	"github.com/boeboe/lictl/pkg/linkedin"
 )
 
 func writeOutput(content, directory, prefix, extension string) (string, error) {
+	directory, err := prepareDirectory(directory)
+	if err != nil {
+		return "", err
+	}
+
+	// Create the unique file
+	file, err := createUniqueFile(directory, prefix, extension)
+	if err != nil {
+		return "", fmt.Errorf("failed to create unique file: %v", err)
+	}
+	defer file.Close()
+
+	// Write content to file
+	if _, err := file.WriteString(content); err != nil {
+		return "", fmt.Errorf("failed to write to file: %v", err)
+	}
+
+	return file.Name(), nil
+}
+
+// prepareDirectory returns directory, or the current working directory when
+// it is empty, creating it if needed.
+func prepareDirectory(directory string) (string, error) {
 	// If directory is empty, use the current working directory
 	if directory == "" {
 		var err error
@@ -26,31 +53,186 @@ func writeOutput(content, directory, prefix, extension string) (string, error) {
 		return "", fmt.Errorf("failed to check directory: %v", err)
 	}
 
-	// Check if directory is writable
-	if dir, err := os.OpenFile(directory, os.O_WRONLY, 0755); err != nil {
-		return "", fmt.Errorf("directory is not writable: %v", err)
-	} else {
-		dir.Close()
+	return directory, nil
+}
+
+func createUniqueFile(directory, prefix, extension string) (*os.File, error) {
+	currentTime := time.Now()
+	timestamp := fmt.Sprintf("%s-%d", currentTime.Format("2006-01-02T15-04-05"), currentTime.Nanosecond())
+	filename := fmt.Sprintf("%s/%s_%s.%s", directory, prefix, timestamp, extension)
+	return os.Create(filename)
+}
+
+// recordWriter writes records to a unique output file as they come in, so the
+// records fetched before a crash are not lost. The file is created with the
+// first record.
+type recordWriter struct {
+	directory string
+	prefix    string
+	format    linkedin.FormatType
+	file      *os.File
+	count     int
+}
+
+func newRecordWriter(directory, prefix string, format linkedin.FormatType) *recordWriter {
+	return &recordWriter{directory: directory, prefix: prefix, format: format}
+}
+
+// Write appends record to the output file.
+func (w *recordWriter) Write(record linkedin.Serializable) error {
+	if err := w.open(); err != nil {
+		return err
 	}
 
-	// Create the unique file
-	file, err := createUniqueFile(directory, prefix, extension)
-	if err != nil {
-		return "", fmt.Errorf("failed to create unique file: %v", err)
+	var content string
+	switch w.format {
+	case linkedin.CSV:
+		if w.count == 0 {
+			content = record.CsvHeader() + "\n"
+		}
+		content += record.CsvContent() + "\n"
+	default:
+		// Indent like ConvertToJSON does for a whole list.
+		content = "  " + strings.ReplaceAll(record.Json(), "\n", "\n  ")
+		if w.count == 0 {
+			content = "[\n" + content
+		} else {
+			content = ",\n" + content
+		}
 	}
-	defer file.Close()
+	if _, err := w.file.WriteString(content); err != nil {
+		return fmt.Errorf("failed to write to file: %v", err)
+	}
+	w.count++
+	return nil
+}
 
-	// Write content to file
-	if _, err := file.WriteString(content); err != nil {
-		return "", fmt.Errorf("failed to write to file: %v", err)
+// Close completes the output file, creating an empty one when no records
+// were written.
+func (w *recordWriter) Close() error {
+	if err := w.open(); err != nil {
+		return err
+	}
+	if w.format != linkedin.CSV {
+		closing := "\n]"
+		if w.count == 0 {
+			closing = "[]"
+		}
+		if _, err := w.file.WriteString(closing); err != nil {
+			w.file.Close()
+			return fmt.Errorf("failed to write to file: %v", err)
+		}
 	}
+	return w.file.Close()
+}
 
-	return file.Name(), nil
+// abort closes the output file after a failed write, if it was created. The
+// file is left as it is, as completing it could fail the same way.
+func (w *recordWriter) abort() {
+	if w.file != nil {
+		w.file.Close()
+	}
 }
 
-func createUniqueFile(directory, prefix, extension string) (*os.File, error) {
-	currentTime := time.Now()
-	timestamp := fmt.Sprintf("%s-%d", currentTime.Format("2006-01-02T15-04-05"), currentTime.Nanosecond())
-	filename := fmt.Sprintf("%s/%s_%s.%s", directory, prefix, timestamp, extension)
-	return os.Create(filename)
+// Name returns the name of the output file, or an empty string when it was
+// not created yet.
+func (w *recordWriter) Name() string {
+	if w.file == nil {
+		return ""
+	}
+	return w.file.Name()
+}
+
+func (w *recordWriter) open() error {
+	if w.file != nil {
+		return nil
+	}
+	directory, err := prepareDirectory(w.directory)
+	if err != nil {
+		return err
+	}
+	file, err := createUniqueFile(directory, w.prefix, w.format.String())
+	if err != nil {
+		return fmt.Errorf("failed to create unique file: %v", err)
+	}
+	w.file = file
+	return nil
+}
+
+// writeHits writes the search engine hits search finds to an output file.
+func writeHits(client *linkedin.ScrapeClient, search func() (linkedin.SearchHits, error)) {
+	writeRecords(client, "hits", func(yield func(*linkedin.SearchHit, error) bool) error {
+		hits, err := search()
+		if err != nil {
+			return err
+		}
+		for _, hit := range hits {
+			if !yield(hit, nil) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// writeRecords writes the records yielded by stream to an output file named
+// after entity as they come in. Errors of single records are reported once
+// stream is done, and a stream that stopped early still leaves the records
+// fetched so far behind. When client resumes from a checkpoint, the records
+// saved there are written first.
+func writeRecords[T linkedin.Serializable](client *linkedin.ScrapeClient, entity string, stream func(yield func(T, error) bool) error) {
+	format, _ := linkedin.SetFormat(formatString)
+	w := newRecordWriter(outputDir, entity, format)
+
+	if checkpoint := client.Checkpoint(); checkpoint != nil && checkpoint.Len() > 0 {
+		records, err := linkedin.CheckpointResults[T](checkpoint)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Printf("Resuming from checkpoint %s with %d %s fetched before.\n", checkpoint.Path(), len(records), entity)
+		for _, record := range records {
+			if err := w.Write(record); err != nil {
+				fmt.Printf("Error writing %s: %v\n", entity, err)
+				w.abort()
+				return
+			}
+		}
+	}
+
+	var recordErrs []error
+	var writeErr error
+	err := stream(func(record T, err error) bool {
+		if err != nil {
+			recordErrs = append(recordErrs, err)
+			return true
+		}
+		writeErr = w.Write(record)
+		return writeErr == nil
+	})
+
+	if writeErr != nil {
+		fmt.Printf("Error writing %s: %v\n", entity, writeErr)
+		w.abort()
+		return
+	}
+	if err != nil {
+		if !isStoppedEarly(err) {
+			printFetchError(err)
+			if w.Name() == "" {
+				return
+			}
+		} else {
+			fmt.Printf("Warning: Stopped fetching %s (%v). Writing the %d %s fetched so far.\n", entity, err, w.count, entity)
+		}
+	}
+	if len(recordErrs) > 0 {
+		printFetchError(fmt.Errorf("encountered errors: %w", errors.Join(recordErrs...)))
+	}
+
+	if closeErr := w.Close(); closeErr != nil {
+		fmt.Printf("Error writing %s: %v\n", entity, closeErr)
+		return
+	}
+	fmt.Printf("%s%s written to file %s\n", strings.ToUpper(entity[:1]), entity[1:], w.Name())
 }
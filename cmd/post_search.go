@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
 
 	"github.com/boeboe/lictl/pkg/linkedin"
 	"github.com/spf13/cobra"
@@ -17,37 +16,29 @@ var postSearchCmd = &cobra.Command{
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 
-		// Fetching posts
-		posts, err := linkedin.SearchPostsOnline(keywords, interval, debug)
+		ctx, cancel := newCommandContext()
+		defer cancel()
+
+		client, err := newScrapeClient(ctx, cmd)
 		if err != nil {
-			if httpErr, ok := err.(*linkedin.HTTPError); ok && httpErr.StatusCode == http.StatusTooManyRequests {
-				fmt.Println("Warning: You've hit the rate limit (HTTP 429 Too Many Requests). Please avoid making further requests for some time.")
-			} else {
-				fmt.Println("Error:", err)
-			}
+			fmt.Println("Error:", err)
 			return
 		}
+		defer closeScrapeClient(client)
 
-		// Writing posts to output file
-		var outErr error
-		var filePath string
-		format, _ := linkedin.SetFormat(formatString)
-
-		switch format {
-		case linkedin.JSON:
-			filePath, outErr = writeOutput(linkedin.ConvertToJSON(posts), outputDir, "posts", "json")
-		case linkedin.CSV:
-			filePath, outErr = writeOutput(linkedin.ConvertToCSV(posts), outputDir, "posts", "json")
-		}
-
-		if outErr != nil {
-			fmt.Println("Error writing posts:", outErr)
-			fmt.Println("Falling back to printing posts:")
-			fmt.Printf("Posts: %+v\n", posts)
+		if hitsOnly {
+			// Writing the search engine hits only, leaving the posts to the get command
+			writeHits(client, func() (linkedin.SearchHits, error) {
+				return linkedin.SearchPostHitsOnlineContext(ctx, client, keywords, debug)
+			})
 			return
 		}
 
-		fmt.Printf("Posts written to file %s\n", filePath)
+		// Fetching posts and writing them to the output file as they come in
+		writeRecords(client, "posts", func(yield func(*linkedin.Post, error) bool) error {
+			return linkedin.StreamPostsOnlineContext(ctx, client, keywords, debug, yield)
+		})
+		printSkipped(client)
 	},
 }
 
@@ -55,4 +46,7 @@ func init() {
 	postCmd.AddCommand(postSearchCmd)
 	addRequiredKeywordsFlag(postSearchCmd)
 	addIntervalFlag(postSearchCmd)
+	addCheckpointFlag(postSearchCmd)
+	addHitsOnlyFlag(postSearchCmd)
+	addConcurrencyFlag(postSearchCmd)
 }
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
 
 	"github.com/boeboe/lictl/pkg/linkedin"
 	"github.com/spf13/cobra"
@@ -17,37 +16,29 @@ var pulseSearchCmd = &cobra.Command{
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 
-		// Fetching pulses
-		pulses, err := linkedin.SearchPulsesOnline(keywords, interval, debug)
+		ctx, cancel := newCommandContext()
+		defer cancel()
+
+		client, err := newScrapeClient(ctx, cmd)
 		if err != nil {
-			if httpErr, ok := err.(*linkedin.HTTPError); ok && httpErr.StatusCode == http.StatusTooManyRequests {
-				fmt.Println("Warning: You've hit the rate limit (HTTP 429 Too Many Requests). Please avoid making further requests for some time.")
-			} else {
-				fmt.Println("Error:", err)
-			}
+			fmt.Println("Error:", err)
 			return
 		}
+		defer closeScrapeClient(client)
 
-		// Writing pulses to output file
-		var outErr error
-		var filePath string
-		format, _ := linkedin.SetFormat(formatString)
-
-		switch format {
-		case linkedin.JSON:
-			filePath, outErr = writeOutput(linkedin.ConvertToJSON(pulses), outputDir, "pulses", "json")
-		case linkedin.CSV:
-			filePath, outErr = writeOutput(linkedin.ConvertToCSV(pulses), outputDir, "pulses", "json")
-		}
-
-		if outErr != nil {
-			fmt.Println("Error writing pulses:", outErr)
-			fmt.Println("Falling back to printing pulses:")
-			fmt.Printf("Pulses: %+v\n", pulses)
+		if hitsOnly {
+			// Writing the search engine hits only, leaving the pulses to the get command
+			writeHits(client, func() (linkedin.SearchHits, error) {
+				return linkedin.SearchPulseHitsOnlineContext(ctx, client, keywords, debug)
+			})
 			return
 		}
 
-		fmt.Printf("Pulses written to file %s\n", filePath)
+		// Fetching pulses and writing them to the output file as they come in
+		writeRecords(client, "pulses", func(yield func(*linkedin.Pulse, error) bool) error {
+			return linkedin.StreamPulsesOnlineContext(ctx, client, keywords, debug, yield)
+		})
+		printSkipped(client)
 	},
 }
 
@@ -55,4 +46,7 @@ func init() {
 	pulseCmd.AddCommand(pulseSearchCmd)
 	addRequiredKeywordsFlag(pulseSearchCmd)
 	addIntervalFlag(pulseSearchCmd)
+	addCheckpointFlag(pulseSearchCmd)
+	addHitsOnlyFlag(pulseSearchCmd)
+	addConcurrencyFlag(pulseSearchCmd)
 }
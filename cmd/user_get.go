@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
 
 	"github.com/boeboe/lictl/pkg/linkedin"
 	"github.com/spf13/cobra"
@@ -17,14 +16,20 @@ var userGetCmd = &cobra.Command{
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 
+		ctx, cancel := newCommandContext()
+		defer cancel()
+
+		client, err := newScrapeClient(ctx, cmd)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		defer closeScrapeClient(client)
+
 		// Fetching user details
-		user, err := linkedin.GetUserFromUrl(urlString, debug)
+		user, err := linkedin.GetUserFromUrlContext(ctx, client, urlString, debug)
 		if err != nil {
-			if httpErr, ok := err.(*linkedin.HTTPError); ok && httpErr.StatusCode == http.StatusTooManyRequests {
-				fmt.Println("Warning: You've hit the rate limit (HTTP 429 Too Many Requests). Please avoid making further requests for some time.")
-			} else {
-				fmt.Println("Error:", err)
-			}
+			printFetchError(err)
 			return
 		}
 
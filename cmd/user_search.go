@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
 
 	"github.com/boeboe/lictl/pkg/linkedin"
 	"github.com/spf13/cobra"
@@ -16,37 +15,29 @@ var userSearchCmd = &cobra.Command{
 		return ValidateFlags(cmd, args)
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		// Fetching users
-		users, err := linkedin.SearchUsersOnline(keywords, interval, debug)
+		ctx, cancel := newCommandContext()
+		defer cancel()
+
+		client, err := newScrapeClient(ctx, cmd)
 		if err != nil {
-			if httpErr, ok := err.(*linkedin.HTTPError); ok && httpErr.StatusCode == http.StatusTooManyRequests {
-				fmt.Println("Warning: You've hit the rate limit (HTTP 429 Too Many Requests). Please avoid making further requests for some time.")
-			} else {
-				fmt.Println("Error:", err)
-			}
+			fmt.Println("Error:", err)
 			return
 		}
+		defer closeScrapeClient(client)
 
-		// Writing users to output file
-		var outErr error
-		var filePath string
-		format, _ := linkedin.SetFormat(formatString)
-
-		switch format {
-		case linkedin.JSON:
-			filePath, outErr = writeOutput(linkedin.ConvertToJSON(users), outputDir, "users", "json")
-		case linkedin.CSV:
-			filePath, outErr = writeOutput(linkedin.ConvertToCSV(users), outputDir, "users", "json")
-		}
-
-		if outErr != nil {
-			fmt.Println("Error writing users:", outErr)
-			fmt.Println("Falling back to printing users:")
-			fmt.Printf("Users: %+v\n", users)
+		if hitsOnly {
+			// Writing the search engine hits only, leaving the users to the get command
+			writeHits(client, func() (linkedin.SearchHits, error) {
+				return linkedin.SearchUserHitsOnlineContext(ctx, client, keywords, debug)
+			})
 			return
 		}
 
-		fmt.Printf("Users written to file %s\n", filePath)
+		// Fetching users and writing them to the output file as they come in
+		writeRecords(client, "users", func(yield func(*linkedin.User, error) bool) error {
+			return linkedin.StreamUsersOnlineContext(ctx, client, keywords, debug, yield)
+		})
+		printSkipped(client)
 	},
 }
 
@@ -54,4 +45,7 @@ func init() {
 	userCmd.AddCommand(userSearchCmd)
 	addRequiredKeywordsFlag(userSearchCmd)
 	addIntervalFlag(userSearchCmd)
+	addCheckpointFlag(userSearchCmd)
+	addHitsOnlyFlag(userSearchCmd)
+	addConcurrencyFlag(userSearchCmd)
 }
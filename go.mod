@@ -3,32 +3,21 @@ module github.com/boeboe/licli
 go 1.21.1
 
 require (
-  github.com/PuerkitoBio/goquery v1.8.1
-  github.com/boeboe/lictl v0.0.0-00010101000000-000000000000
-  github.com/corpix/uarand v0.2.0
-  github.com/rocketlaunchr/google-search v1.1.6
-  github.com/spf13/cobra v1.7.0
+	github.com/PuerkitoBio/goquery v1.8.1
+	github.com/andybalholm/brotli v1.1.1
+	github.com/boeboe/lictl v0.0.0-00010101000000-000000000000
+	github.com/corpix/uarand v0.2.0
+	github.com/spf13/cobra v1.7.0
+	github.com/temoto/robotstxt v1.1.2
+	golang.org/x/net v0.10.0
+	golang.org/x/text v0.9.0
+	golang.org/x/time v0.3.0
 )
 
 require (
-  github.com/andybalholm/cascadia v1.3.2 // indirect
-  github.com/antchfx/htmlquery v1.3.0 // indirect
-  github.com/antchfx/xmlquery v1.3.15 // indirect
-  github.com/antchfx/xpath v1.2.4 // indirect
-  github.com/gobwas/glob v0.2.3 // indirect
-  github.com/gocolly/colly/v2 v2.1.0 // indirect
-  github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
-  github.com/golang/protobuf v1.5.3 // indirect
-  github.com/inconshreveable/mousetrap v1.1.0 // indirect
-  github.com/kennygrant/sanitize v1.2.4 // indirect
-  github.com/saintfish/chardet v0.0.0-20230101081208-5e3ef4b5456d // indirect
-  github.com/spf13/pflag v1.0.5 // indirect
-  github.com/temoto/robotstxt v1.1.2 // indirect
-  golang.org/x/net v0.10.0 // indirect
-  golang.org/x/text v0.9.0 // indirect
-  golang.org/x/time v0.3.0 // indirect
-  google.golang.org/appengine v1.6.7 // indirect
-  google.golang.org/protobuf v1.30.0 // indirect
+	github.com/andybalholm/cascadia v1.3.2 // indirect
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/spf13/pflag v1.0.5 // indirect
 )
 
 replace github.com/boeboe/lictl => ./
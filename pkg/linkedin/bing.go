@@ -0,0 +1,101 @@
+package linkedin
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	bingHost = "www.bing.com"
+	// bingPageSize is the largest number of results Bing serves per page.
+	bingPageSize = 50
+)
+
+// BingEngine searches Bing through its HTML result pages.
+type BingEngine struct{}
+
+func (BingEngine) Name() string {
+	return "bing"
+}
+
+// Search scrapes the Bing result pages of query, following the next page
+// link until searchMaxResults is reached.
+func (BingEngine) Search(ctx context.Context, client *ScrapeClient, query *Query) (SearchHits, error) {
+	return searchPages(ctx, client, bingSearchURL(query), parseBingResults)
+}
+
+func bingSearchURL(query *Query) string {
+	params := url.Values{}
+	params.Set("q", query.SearchTerms())
+	params.Set("setlang", "en")
+	params.Set("count", strconv.Itoa(bingPageSize))
+	if filter := bingRecencyFilter(query.Posted(), time.Now()); filter != "" {
+		params.Set("filters", filter)
+	}
+	return "https://" + bingHost + "/search?" + params.Encode()
+}
+
+// bingRecencyFilter returns the filters parameter restricting results to
+// recency. Bing has presets up to a month, and takes longer periods as a
+// range of days since the Unix epoch.
+func bingRecencyFilter(recency Recency, now time.Time) string {
+	switch recency {
+	case RecencyDay:
+		return `ex1:"ez1"`
+	case RecencyWeek:
+		return `ex1:"ez2"`
+	case RecencyMonth:
+		return `ex1:"ez3"`
+	case RecencyYear:
+		to := now.Unix() / 86400
+		from := now.AddDate(-1, 0, 0).Unix() / 86400
+		return fmt.Sprintf(`ex1:"ez5_%d_%d"`, from, to)
+	}
+	return ""
+}
+
+// parseBingResults returns the hits of a Bing result page and the absolute
+// URL of the next page, if any. Ads are not part of li.b_algo.
+func parseBingResults(doc *goquery.Document, pageURL *url.URL) (SearchHits, string) {
+	var hits SearchHits
+	doc.Find("li.b_algo").Each(func(i int, s *goquery.Selection) {
+		link := s.Find("h2 a").First()
+		href, _ := link.Attr("href")
+		if target := bingTarget(resolveURL(pageURL, href)); target != "" {
+			hits = append(hits, &SearchHit{
+				URL:     target,
+				Title:   cleanText(link),
+				Snippet: cleanText(s.Find(".b_caption p").First()),
+			})
+		}
+	})
+
+	next, exists := doc.Find("a.sb_pagN").First().Attr("href")
+	if !exists {
+		return hits, ""
+	}
+	return hits, resolveURL(pageURL, next)
+}
+
+// bingTarget returns the URL a Bing click tracking link leads to, which is
+// the base64 encoded u parameter after an "a1" marker. Other links are
+// returned as is.
+func bingTarget(href string) string {
+	u, err := url.Parse(href)
+	if err != nil || !strings.HasSuffix(u.Hostname(), "bing.com") || u.Path != "/ck/a" {
+		return href
+	}
+	encoded := strings.TrimPrefix(u.Query().Get("u"), "a1")
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil {
+		return ""
+	}
+	return string(decoded)
+}
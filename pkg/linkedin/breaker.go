@@ -0,0 +1,186 @@
+package linkedin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when the circuit breaker stopped a fetch because
+// LinkedIn kept blocking the previous ones.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitBreaker stops fetching once LinkedIn answers several requests in a
+// row with a rate limit, an auth wall or a captcha. With a cooldown it pauses
+// until the cooldown expires and then lets a single probe through, holding
+// back the other fetches until the probe is recorded; without a cooldown
+// every further fetch is aborted.
+type CircuitBreaker struct {
+	mu          sync.Mutex
+	threshold   int
+	cooldown    time.Duration
+	consecutive int
+	open        bool
+	openedAt    time.Time
+	last        error
+
+	// probing is set while the breaker is half open, until the outcome of
+	// the probe is recorded and probed is closed.
+	probing bool
+	probed  chan struct{}
+}
+
+// NewCircuitBreaker creates a CircuitBreaker that opens after threshold
+// consecutive block signals. A cooldown of 0 aborts instead of pausing.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// Allow returns nil when a fetch may proceed. When the breaker is open, it
+// either waits for the cooldown to expire or returns a *CircuitOpenError.
+// Once the cooldown expired, a single caller gets through as the probe, and
+// the others wait until its outcome is recorded. probe reports whether the
+// caller is the probe, and must be passed on to Record.
+func (b *CircuitBreaker) Allow(ctx context.Context) (probe bool, err error) {
+	for {
+		b.mu.Lock()
+		if b.probing {
+			probed := b.probed
+			b.mu.Unlock()
+			select {
+			case <-probed:
+				continue
+			case <-ctx.Done():
+				return false, ctx.Err()
+			}
+		}
+		if !b.open {
+			b.mu.Unlock()
+			return false, nil
+		}
+		if b.cooldown <= 0 {
+			err := &CircuitOpenError{Consecutive: b.consecutive, Last: b.last}
+			b.mu.Unlock()
+			return false, err
+		}
+		wait := time.Until(b.openedAt.Add(b.cooldown))
+		if wait <= 0 {
+			b.open = false
+			b.probing = true
+			b.probed = make(chan struct{})
+			b.mu.Unlock()
+			return true, nil
+		}
+		b.mu.Unlock()
+
+		if err := sleepContext(ctx, wait); err != nil {
+			return false, err
+		}
+	}
+}
+
+// Record updates the breaker with the outcome of a fetch, and probe with
+// whether Allow let it through as the probe. While the breaker is half open,
+// only the outcome of the probe counts, so fetches allowed before the breaker
+// opened cannot close or open it again.
+func (b *CircuitBreaker) Record(probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe && b.probing {
+		b.recordProbe(err)
+		return
+	}
+	if b.probing {
+		return
+	}
+	if !isBlockSignal(err) {
+		if err == nil {
+			b.consecutive = 0
+		}
+		return
+	}
+	b.consecutive++
+	b.last = err
+	if b.consecutive >= b.threshold && !b.open {
+		b.open = true
+		b.openedAt = time.Now()
+	}
+}
+
+// recordProbe closes the half open breaker with the outcome of the probe.
+func (b *CircuitBreaker) recordProbe(err error) {
+	b.probing = false
+	close(b.probed)
+	switch {
+	case isBlockSignal(err):
+		b.consecutive = b.threshold
+		b.last = err
+		b.open = true
+		b.openedAt = time.Now()
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		// The probe got no answer, so the next caller probes instead.
+		b.open = true
+	case err == nil:
+		b.consecutive = 0
+	default:
+		// One more block signal opens the breaker again.
+		b.consecutive = b.threshold - 1
+	}
+}
+
+// Open reports whether the breaker is currently open.
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
+
+func isBlockSignal(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrAuthWall) || errors.Is(err, ErrCaptcha)
+}
+
+// CircuitOpenError reports that a crawl was stopped by the circuit breaker.
+// It matches both ErrCircuitOpen and the last block signal with errors.Is.
+type CircuitOpenError struct {
+	Consecutive int
+	Skipped     int
+	Last        error
+}
+
+func (e *CircuitOpenError) Error() string {
+	msg := fmt.Sprintf("stopped after %d consecutive blocked requests", e.Consecutive)
+	if e.Skipped > 0 {
+		msg = fmt.Sprintf("%s, skipped %d URLs", msg, e.Skipped)
+	}
+	if e.Last != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Last)
+	}
+	return msg
+}
+
+func (e *CircuitOpenError) Unwrap() []error {
+	return []error{ErrCircuitOpen, e.Last}
+}
+
+// skipRemaining returns err with the number of skipped URLs set when it is a
+// *CircuitOpenError or a *BudgetError, and nil otherwise.
+func skipRemaining(err error, skipped int) error {
+	var openErr *CircuitOpenError
+	if errors.As(err, &openErr) {
+		stopped := *openErr
+		stopped.Skipped = skipped
+		return &stopped
+	}
+	var budgetErr *BudgetError
+	if errors.As(err, &budgetErr) {
+		stopped := *budgetErr
+		stopped.Skipped = skipped
+		return &stopped
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package linkedin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerAbort(t *testing.T) {
+	b := NewCircuitBreaker(2, 0)
+	blocked := &HTTPError{StatusCode: http.StatusTooManyRequests, Kind: ErrRateLimited}
+
+	b.Record(false, blocked)
+	b.Record(false, errors.New("connection reset"))
+	if b.Open() {
+		t.Fatalf("Expected breaker to stay closed after one block signal")
+	}
+	b.Record(false, blocked)
+	if !b.Open() {
+		t.Fatalf("Expected breaker to open after two consecutive block signals")
+	}
+
+	_, err := b.Allow(context.Background())
+	if !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected ErrCircuitOpen wrapping ErrRateLimited, but got %v", err)
+	}
+
+	err = skipRemaining(err, 5)
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || openErr.Skipped != 5 {
+		t.Errorf("Expected 5 skipped URLs, but got %v", err)
+	}
+	if skipRemaining(blocked, 5) != nil {
+		t.Errorf("Expected no CircuitOpenError for a plain block signal")
+	}
+}
+
+func TestCircuitBreakerPause(t *testing.T) {
+	b := NewCircuitBreaker(1, 20*time.Millisecond)
+	b.Record(false, &HTTPError{Kind: ErrAuthWall})
+
+	start := time.Now()
+	probe, err := b.Allow(context.Background())
+	if err != nil || !probe {
+		t.Fatalf("Expected breaker to pause instead of abort and let a probe through, but got %v", err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Errorf("Expected breaker to pause for the cooldown")
+	}
+	if b.Open() {
+		t.Errorf("Expected breaker to be half open after the cooldown")
+	}
+
+	b.Record(true, &HTTPError{Kind: ErrCaptcha})
+	if !b.Open() {
+		t.Errorf("Expected a failed probe to open the breaker again")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := b.Allow(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected pause to stop on cancel, but got %v", err)
+	}
+}
+
+func TestCircuitBreakerStopsFetching(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(statusLinkedInBlocked)
+	}))
+	defer server.Close()
+
+	client := NewScrapeClient(WithRetries(1), WithCircuitBreaker(NewCircuitBreaker(2, 0)))
+	for i := 0; i < 4; i++ {
+		_, err := GetCompanyFromUrl(client, server.URL, false)
+		if i >= 2 && !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected fetch %d to be stopped by the breaker, but got %v", i, err)
+		}
+	}
+	if hits != 2 {
+		t.Errorf("Expected 2 requests to reach the server, but got %d", hits)
+	}
+}
+
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	b := NewCircuitBreaker(1, 10*time.Millisecond)
+	b.Record(false, &HTTPError{Kind: ErrRateLimited})
+
+	allowed := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := b.Allow(context.Background())
+			allowed <- err
+		}()
+	}
+
+	// Only the probe gets through until its outcome is recorded.
+	if err := <-allowed; err != nil {
+		t.Fatalf("Expected the probe to get through, but got %v", err)
+	}
+	select {
+	case err := <-allowed:
+		t.Fatalf("Expected the other fetches to wait for the probe, but one got through with %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// A fetch allowed before the breaker opened does not decide for the
+	// probe.
+	b.Record(false, nil)
+	select {
+	case err := <-allowed:
+		t.Fatalf("Expected the other fetches to wait for the probe, but one got through with %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	b.Record(true, nil)
+	for i := 0; i < 4; i++ {
+		select {
+		case err := <-allowed:
+			if err != nil {
+				t.Errorf("Expected the waiting fetches to get through after a successful probe, but got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected the waiting fetches to get through after a successful probe")
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package linkedin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ledgerDayFormat  = "2006-01-02"
+	ledgerHourFormat = "2006-01-02T15"
+
+	// ledgerKeepDays is how long request counts are kept in the ledger.
+	ledgerKeepDays = 7
+
+	ledgerLockTimeout = 10 * time.Second
+	ledgerLockRetry   = 10 * time.Millisecond
+	// ledgerLockStale is the age after which a lock file is assumed to be
+	// left behind by a crashed process. The holder of a lock touches its file
+	// every ledgerLockRefresh, so a live lock never gets that old.
+	ledgerLockStale   = 30 * time.Second
+	ledgerLockRefresh = 10 * time.Second
+)
+
+// ErrBudgetExhausted is returned when a request would exceed a request
+// budget. Use errors.As with *BudgetError for the details.
+var ErrBudgetExhausted = errors.New("request budget exhausted")
+
+// BudgetError reports that a crawl was stopped because a request budget ran
+// out. It matches ErrBudgetExhausted with errors.Is.
+type BudgetError struct {
+	Host    string
+	Window  string
+	Limit   int
+	Skipped int
+}
+
+func (e *BudgetError) Error() string {
+	msg := fmt.Sprintf("%s budget of %d requests to %s used up", e.Window, e.Limit, e.Host)
+	if e.Skipped > 0 {
+		msg = fmt.Sprintf("%s, skipped %d URLs", msg, e.Skipped)
+	}
+	return msg
+}
+
+func (e *BudgetError) Unwrap() error {
+	return ErrBudgetExhausted
+}
+
+// RequestLedger counts the requests sent per host per day and per hour in a
+// file, and enforces request budgets on them. The file is locked while it is
+// updated, so separate processes sharing it share the budgets.
+type RequestLedger struct {
+	path string
+	now  func() time.Time
+
+	mu     sync.Mutex
+	daily  map[string]int
+	hourly map[string]int
+}
+
+type ledgerState struct {
+	Hosts map[string]*ledgerHost `json:"hosts"`
+}
+
+type ledgerHost struct {
+	Days  map[string]int `json:"days"`
+	Hours map[string]int `json:"hours"`
+}
+
+// NewRequestLedger creates a RequestLedger kept in path.
+func NewRequestLedger(path string) *RequestLedger {
+	return &RequestLedger{
+		path:   path,
+		now:    time.Now,
+		daily:  make(map[string]int),
+		hourly: make(map[string]int),
+	}
+}
+
+// SetDailyBudget limits the requests to host per day. A domain such as
+// linkedin.com limits all its subdomains together, and an empty host limits
+// every host on its own.
+func (l *RequestLedger) SetDailyBudget(host string, limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.daily[strings.ToLower(host)] = limit
+}
+
+// SetHourlyBudget limits the requests to host per hour, like SetDailyBudget.
+func (l *RequestLedger) SetHourlyBudget(host string, limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hourly[strings.ToLower(host)] = limit
+}
+
+// Usage returns the number of requests sent to host today and this hour.
+func (l *RequestLedger) Usage(host string) (int, int, error) {
+	state, err := l.load()
+	if err != nil {
+		return 0, 0, err
+	}
+	now := l.now()
+	day, hour := now.Format(ledgerDayFormat), now.Format(ledgerHourFormat)
+	h := state.Hosts[strings.ToLower(host)]
+	if h == nil {
+		return 0, 0, nil
+	}
+	return h.Days[day], h.Hours[hour], nil
+}
+
+// take counts a request to host, or returns a *BudgetError when that would
+// exceed one of the budgets.
+func (l *RequestLedger) take(host string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	unlock, err := lockFile(l.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, err := l.load()
+	if err != nil {
+		return err
+	}
+
+	host = strings.ToLower(host)
+	now := l.now()
+	day, hour := now.Format(ledgerDayFormat), now.Format(ledgerHourFormat)
+	if err := checkBudget(state, l.daily, host, "daily", func(h *ledgerHost) int { return h.Days[day] }); err != nil {
+		return err
+	}
+	if err := checkBudget(state, l.hourly, host, "hourly", func(h *ledgerHost) int { return h.Hours[hour] }); err != nil {
+		return err
+	}
+
+	h := state.Hosts[host]
+	if h == nil {
+		h = &ledgerHost{Days: make(map[string]int), Hours: make(map[string]int)}
+		state.Hosts[host] = h
+	}
+	h.Days[day]++
+	h.Hours[hour]++
+	pruneLedger(state, now)
+	return l.save(state)
+}
+
+// checkBudget checks the budgets that apply to host against the requests
+// counted by count.
+func checkBudget(state *ledgerState, budgets map[string]int, host, window string, count func(*ledgerHost) int) error {
+	for budgetHost, limit := range budgets {
+		used := 0
+		switch {
+		case budgetHost == "":
+			if h := state.Hosts[host]; h != nil {
+				used = count(h)
+			}
+			budgetHost = host
+		case matchesDomain(host, budgetHost):
+			for name, h := range state.Hosts {
+				if matchesDomain(name, budgetHost) {
+					used += count(h)
+				}
+			}
+		default:
+			continue
+		}
+		if used >= limit {
+			return &BudgetError{Host: budgetHost, Window: window, Limit: limit}
+		}
+	}
+	return nil
+}
+
+// matchesDomain reports whether host is domain or one of its subdomains.
+func matchesDomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// pruneLedger drops the counts that no budget looks at anymore.
+func pruneLedger(state *ledgerState, now time.Time) {
+	oldestDay := now.AddDate(0, 0, -ledgerKeepDays).Format(ledgerDayFormat)
+	oldestHour := now.Add(-24 * time.Hour).Format(ledgerHourFormat)
+	for name, h := range state.Hosts {
+		for day := range h.Days {
+			if day < oldestDay {
+				delete(h.Days, day)
+			}
+		}
+		for hour := range h.Hours {
+			if hour < oldestHour {
+				delete(h.Hours, hour)
+			}
+		}
+		if len(h.Days) == 0 {
+			delete(state.Hosts, name)
+		}
+	}
+}
+
+func (l *RequestLedger) load() (*ledgerState, error) {
+	state := &ledgerState{Hosts: make(map[string]*ledgerHost)}
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open request ledger: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to read request ledger %s: %w", l.path, err)
+	}
+	if state.Hosts == nil {
+		state.Hosts = make(map[string]*ledgerHost)
+	}
+	for _, h := range state.Hosts {
+		if h.Days == nil {
+			h.Days = make(map[string]int)
+		}
+		if h.Hours == nil {
+			h.Hours = make(map[string]int)
+		}
+	}
+	return state, nil
+}
+
+func (l *RequestLedger) save(state *ledgerState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(l.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write request ledger: %w", err)
+	}
+	return nil
+}
+
+// lockFile takes an exclusive lock by creating path, which works the same on
+// every platform, and writes the PID of the process into it. It returns the
+// function that releases the lock.
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(ledgerLockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			return holdLock(path, file)
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock request ledger: %w", err)
+		}
+		if removeStaleLock(path) {
+			continue
+		}
+		if time.Now().After(deadline) {
+			holder, _ := os.ReadFile(path)
+			return nil, fmt.Errorf("failed to lock request ledger: %s is held by process %s", path, strings.TrimSpace(string(holder)))
+		}
+		time.Sleep(ledgerLockRetry)
+	}
+}
+
+// holdLock writes the PID into the lock file just created at path, and keeps
+// touching it until the lock is released.
+func holdLock(path string, file *os.File) (func(), error) {
+	_, err := fmt.Fprintf(file, "%d\n", os.Getpid())
+	info, statErr := file.Stat()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = statErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to lock request ledger: %w", err)
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(ledgerLockRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				os.Chtimes(path, now, now)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		// A lock taken over by another process is not ours to remove.
+		if current, err := os.Stat(path); err == nil && os.SameFile(info, current) {
+			os.Remove(path)
+		}
+	}, nil
+}
+
+// removeStaleLock removes the lock file at path when it is older than
+// ledgerLockStale, and reports whether it did. The file is moved aside first,
+// so a fresh lock created by another process in the meantime is put back
+// instead of removed.
+func removeStaleLock(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) <= ledgerLockStale {
+		return false
+	}
+	aside := fmt.Sprintf("%s.%d", path, os.Getpid())
+	if err := os.Rename(path, aside); err != nil {
+		return false
+	}
+	if moved, err := os.Stat(aside); err == nil && !os.SameFile(info, moved) {
+		os.Link(aside, path)
+	}
+	os.Remove(aside)
+	return true
+}
+
+// ParseBudget parses a request budget in "host=limit" form, or a bare limit
+// that applies to every host on its own. The limit must be at least 1, as a
+// budget of 0 would block every request.
+func ParseBudget(s string) (string, int, error) {
+	host, limitString, found := strings.Cut(s, "=")
+	if !found {
+		host, limitString = "", s
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(limitString))
+	if err != nil || limit < 1 {
+		return "", 0, fmt.Errorf("invalid budget %q: expected \"host=limit\" or \"limit\" with a limit of at least 1", s)
+	}
+	return strings.ToLower(strings.TrimSpace(host)), limit, nil
+}
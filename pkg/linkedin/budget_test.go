@@ -0,0 +1,155 @@
+package linkedin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBudget(t *testing.T) {
+	tests := []struct {
+		input   string
+		host    string
+		limit   int
+		wantErr bool
+	}{
+		{"500", "", 500, false},
+		{"www.LinkedIn.com=100", "www.linkedin.com", 100, false},
+		{"google.com = 20", "google.com", 20, false},
+		{"linkedin.com=", "", 0, true},
+		{"linkedin.com=-1", "", 0, true},
+		{"0", "", 0, true},
+		{"many", "", 0, true},
+	}
+
+	for _, test := range tests {
+		host, limit, err := ParseBudget(test.input)
+		if (err != nil) != test.wantErr {
+			t.Errorf("Expected error %v for %q, but got %v", test.wantErr, test.input, err)
+			continue
+		}
+		if host != test.host || limit != test.limit {
+			t.Errorf("Expected %q=%d for %q, but got %q=%d", test.host, test.limit, test.input, host, limit)
+		}
+	}
+}
+
+func TestRequestLedgerBudgets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	now := time.Date(2023, 10, 1, 12, 30, 0, 0, time.UTC)
+
+	// Two ledgers on the same file act like two separate runs.
+	newLedger := func() *RequestLedger {
+		ledger := NewRequestLedger(path)
+		ledger.now = func() time.Time { return now }
+		ledger.SetDailyBudget("linkedin.com", 3)
+		ledger.SetHourlyBudget("", 2)
+		return ledger
+	}
+	first, second := newLedger(), newLedger()
+
+	steps := []struct {
+		ledger *RequestLedger
+		host   string
+		window string
+	}{
+		{first, "www.linkedin.com", ""},
+		{second, "www.linkedin.com", ""},
+		{first, "www.linkedin.com", "hourly"},
+		{second, "nl.linkedin.com", ""},
+		{first, "www.google.com", ""},
+		{second, "nl.linkedin.com", "daily"},
+	}
+	for i, step := range steps {
+		if i == 3 {
+			now = now.Add(time.Hour)
+		}
+		err := step.ledger.take(step.host)
+		var budgetErr *BudgetError
+		switch {
+		case step.window == "" && err != nil:
+			t.Errorf("Expected request %d to %s within budget, but got %v", i, step.host, err)
+		case step.window != "" && (!errors.As(err, &budgetErr) || budgetErr.Window != step.window):
+			t.Errorf("Expected request %d to %s to exceed the %s budget, but got %v", i, step.host, step.window, err)
+		}
+	}
+
+	day, hour, err := first.Usage("www.linkedin.com")
+	if err != nil || day != 2 || hour != 0 {
+		t.Errorf("Expected 2 requests today and 0 this hour, but got %d and %d (%v)", day, hour, err)
+	}
+}
+
+func TestRequestLedgerStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	lock := path + ".lock"
+	if err := os.WriteFile(lock, nil, 0644); err != nil {
+		t.Fatalf("Expected no error writing the lock file, but got %v", err)
+	}
+	old := time.Now().Add(-2 * ledgerLockStale)
+	if err := os.Chtimes(lock, old, old); err != nil {
+		t.Fatalf("Expected no error aging the lock file, but got %v", err)
+	}
+
+	if err := NewRequestLedger(path).take("www.linkedin.com"); err != nil {
+		t.Errorf("Expected a stale lock to be taken over, but got %v", err)
+	}
+	if _, err := os.Stat(lock); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock file to be removed, but got %v", err)
+	}
+}
+
+func TestRequestLedgerLockTakenOver(t *testing.T) {
+	lock := filepath.Join(t.TempDir(), "ledger.json.lock")
+	unlock, err := lockFile(lock)
+	if err != nil {
+		t.Fatalf("Expected no error locking, but got %v", err)
+	}
+	unlockOther, err := lockFile(lock + ".other")
+	if err != nil {
+		t.Fatalf("Expected no error locking another file, but got %v", err)
+	}
+	defer unlockOther()
+
+	// Another process took the lock over, so releasing ours leaves its lock
+	// file alone.
+	if err := os.Rename(lock+".other", lock); err != nil {
+		t.Fatalf("Expected no error replacing the lock file, but got %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(lock); err != nil {
+		t.Errorf("Expected the lock file of the other process to stay, but got %v", err)
+	}
+}
+
+func TestStreamAllBudget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/company/")
+		fmt.Fprintf(w, `<html><body><h1 class="top-card-layout__title">%s</h1></body></html>`, name)
+	}))
+	defer server.Close()
+
+	var urls []string
+	for i := 0; i < 5; i++ {
+		urls = append(urls, fmt.Sprintf("%s/company/c%d", server.URL, i))
+	}
+
+	ledger := NewRequestLedger(filepath.Join(t.TempDir(), "ledger.json"))
+	ledger.SetDailyBudget("", 2)
+	client := NewScrapeClient(WithRequestLedger(ledger))
+	companies, err := collectCompanies(client, urls)
+
+	var budgetErr *BudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Skipped != 3 {
+		t.Errorf("Expected a budget error skipping 3 URLs, but got %v", err)
+	}
+	if len(companies) != 2 {
+		t.Errorf("Expected the 2 companies fetched within budget, but got %d", len(companies))
+	}
+}
@@ -0,0 +1,242 @@
+package linkedin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCacheMiss is returned in offline mode when a page is not in the cache.
+var ErrCacheMiss = errors.New("cache miss")
+
+// EntityType identifies the kind of page behind a URL, which determines how
+// long it stays fresh in the cache.
+type EntityType string
+
+const (
+	EntityJob     EntityType = "job"
+	EntityCompany EntityType = "company"
+	EntityPost    EntityType = "post"
+	EntityPulse   EntityType = "pulse"
+	EntityUser    EntityType = "user"
+	EntitySearch  EntityType = "search"
+	EntityOther   EntityType = "other"
+)
+
+// DefaultCacheTTLs are the default freshness lifetimes per entity type. Job
+// listings change quickly, companies hardly ever.
+var DefaultCacheTTLs = map[EntityType]time.Duration{
+	EntityJob:     time.Hour,
+	EntityCompany: 30 * 24 * time.Hour,
+	EntityPost:    24 * time.Hour,
+	EntityPulse:   7 * 24 * time.Hour,
+	EntityUser:    7 * 24 * time.Hour,
+	EntitySearch:  24 * time.Hour,
+	EntityOther:   24 * time.Hour,
+}
+
+// SetEntityType sets the entity type from a string, handling
+// case-insensitivity.
+func SetEntityType(s string) (EntityType, error) {
+	for entityType := range DefaultCacheTTLs {
+		if strings.EqualFold(s, string(entityType)) {
+			return entityType, nil
+		}
+	}
+	return "", fmt.Errorf("unknown entity type: %s", s)
+}
+
+// ResponseCache stores page bodies on disk, in files named after the SHA-256
+// of their normalized URL. Concurrent fetches of the same URL within one run
+// are coalesced into a single request.
+type ResponseCache struct {
+	dir     string
+	offline bool
+
+	mu       sync.Mutex
+	ttls     map[EntityType]time.Duration
+	inflight map[string]*cacheCall
+}
+
+type cacheCall struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+type cacheEntry struct {
+	Key      string    `json:"key"`
+	StoredAt time.Time `json:"storedAt"`
+	Body     []byte    `json:"body"`
+}
+
+// NewResponseCache creates a ResponseCache in dir, creating it if needed.
+func NewResponseCache(dir string) (*ResponseCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	ttls := make(map[EntityType]time.Duration, len(DefaultCacheTTLs))
+	for entityType, ttl := range DefaultCacheTTLs {
+		ttls[entityType] = ttl
+	}
+	return &ResponseCache{
+		dir:      dir,
+		ttls:     ttls,
+		inflight: make(map[string]*cacheCall),
+	}, nil
+}
+
+// SetTTL sets how long pages of the given entity type stay fresh.
+func (c *ResponseCache) SetTTL(entityType EntityType, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttls[entityType] = ttl
+}
+
+// SetOffline makes the cache serve only stored pages, ignoring their age,
+// and fail with ErrCacheMiss instead of fetching.
+func (c *ResponseCache) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+// Offline reports whether the cache is in offline mode.
+func (c *ResponseCache) Offline() bool {
+	return c.offline
+}
+
+// Get returns the stored body for key if it is still fresh for entityType.
+func (c *ResponseCache) Get(key string, entityType EntityType) ([]byte, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return nil, false
+	}
+	if !c.offline && time.Since(entry.StoredAt) > c.ttl(entityType) {
+		return nil, false
+	}
+	return entry.Body, true
+}
+
+// Put stores body for key.
+func (c *ResponseCache) Put(key string, body []byte) error {
+	data, err := json.Marshal(cacheEntry{Key: key, StoredAt: time.Now(), Body: body})
+	if err != nil {
+		return err
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	if err := writeFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// fetch returns the body for key from the cache, or calls fetch and stores
+// its result. Concurrent calls for the same key share one fetch.
+func (c *ResponseCache) fetch(key string, entityType EntityType, fetch func() ([]byte, error)) ([]byte, error) {
+	if body, ok := c.Get(key, entityType); ok {
+		return body, nil
+	}
+	if c.offline {
+		return nil, fmt.Errorf("%w: %s", ErrCacheMiss, key)
+	}
+
+	c.mu.Lock()
+	if call, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.body, call.err
+	}
+	call := &cacheCall{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	call.body, call.err = fetch()
+	if call.err == nil {
+		if err := c.Put(key, call.body); err != nil {
+			log.Printf("failed to cache %s: %v", key, err)
+		}
+	}
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	c.mu.Unlock()
+	close(call.done)
+	return call.body, call.err
+}
+
+func (c *ResponseCache) ttl(entityType EntityType) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ttls[entityType]
+}
+
+func (c *ResponseCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, name[:2], name+".json")
+}
+
+// trackingParams are query parameters that do not change the page served.
+var trackingParams = []string{"trk", "trackingId", "refId", "originalSubdomain", "lipi"}
+
+// normalizeURL returns the cache key of u: lower case scheme and host, no
+// fragment, no tracking parameters and sorted query parameters.
+func normalizeURL(u *url.URL) string {
+	normalized := *u
+	normalized.Scheme = strings.ToLower(normalized.Scheme)
+	normalized.Host = strings.ToLower(normalized.Host)
+	normalized.Fragment = ""
+	normalized.RawFragment = ""
+	normalized.Path = strings.TrimSuffix(normalized.Path, "/")
+	normalized.RawPath = strings.TrimSuffix(normalized.RawPath, "/")
+
+	query := normalized.Query()
+	for _, param := range trackingParams {
+		query.Del(param)
+	}
+	// Encode sorts the query parameters by key.
+	normalized.RawQuery = query.Encode()
+	return normalized.String()
+}
+
+// entityTypeOf guesses the entity type of a LinkedIn URL from its path.
+func entityTypeOf(u *url.URL) EntityType {
+	prefixes := []struct {
+		prefix     string
+		entityType EntityType
+	}{
+		{"/jobs", EntityJob},
+		{"/company/", EntityCompany},
+		{"/posts/", EntityPost},
+		{"/pulse/", EntityPulse},
+		{"/in/", EntityUser},
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(u.Path, p.prefix) {
+			return p.entityType
+		}
+	}
+	return EntityOther
+}
+
+// searchCacheKey returns the cache key of a search engine query.
+func searchCacheKey(engine, query string) string {
+	return fmt.Sprintf("%s:%s", engine, query)
+}
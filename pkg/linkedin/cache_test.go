@@ -0,0 +1,148 @@
+package linkedin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"https://WWW.LinkedIn.com/company/tetrate/?trk=public_jobs", "https://www.linkedin.com/company/tetrate"},
+		{"https://www.linkedin.com/jobs/search?start=25&keywords=go#top", "https://www.linkedin.com/jobs/search?keywords=go&start=25"},
+		{"https://www.linkedin.com/in/someone?originalSubdomain=be", "https://www.linkedin.com/in/someone"},
+	}
+
+	for _, test := range tests {
+		u, _ := url.Parse(test.input)
+		result := normalizeURL(u)
+		if result != test.expected {
+			t.Errorf("For input %s, expected %s, but got %s", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestEntityTypeOf(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected EntityType
+	}{
+		{"https://www.linkedin.com/jobs-guest/jobs/api/seeMoreJobPostings/search?start=0", EntityJob},
+		{"https://www.linkedin.com/jobs/view/123", EntityJob},
+		{"https://www.linkedin.com/company/tetrate", EntityCompany},
+		{"https://www.linkedin.com/posts/someone_activity-123", EntityPost},
+		{"https://www.linkedin.com/pulse/some-article", EntityPulse},
+		{"https://www.linkedin.com/in/someone", EntityUser},
+		{"https://www.linkedin.com/", EntityOther},
+	}
+
+	for _, test := range tests {
+		u, _ := url.Parse(test.input)
+		result := entityTypeOf(u)
+		if result != test.expected {
+			t.Errorf("For input %s, expected %s, but got %s", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestResponseCacheTTLAndOffline(t *testing.T) {
+	cache, err := NewResponseCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error creating cache: %v", err)
+	}
+
+	if err := cache.Put("key", []byte("body")); err != nil {
+		t.Fatalf("Error storing cache entry: %v", err)
+	}
+	if body, ok := cache.Get("key", EntityCompany); !ok || string(body) != "body" {
+		t.Errorf("Expected fresh cache hit, but got %q (%v)", body, ok)
+	}
+
+	cache.SetTTL(EntityJob, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok := cache.Get("key", EntityJob); ok {
+		t.Errorf("Expected stale entry to be a cache miss")
+	}
+
+	cache.SetOffline(true)
+	if _, ok := cache.Get("key", EntityJob); !ok {
+		t.Errorf("Expected offline mode to serve stale entries")
+	}
+	_, err = cache.fetch("other", EntityJob, func() ([]byte, error) {
+		t.Errorf("Expected offline mode not to fetch")
+		return nil, nil
+	})
+	if !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss in offline mode, but got %v", err)
+	}
+}
+
+func TestResponseCacheCoalescing(t *testing.T) {
+	cache, _ := NewResponseCache(t.TempDir())
+
+	var fetches int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, err := cache.fetch("key", EntityCompany, func() ([]byte, error) {
+				atomic.AddInt32(&fetches, 1)
+				<-release
+				return []byte("body"), nil
+			})
+			if err != nil || string(body) != "body" {
+				t.Errorf("Expected shared body, but got %q (%v)", body, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if fetches != 1 {
+		t.Errorf("Expected 1 fetch for concurrent requests, but got %d", fetches)
+	}
+}
+
+func TestScrapeClientCache(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte(`<html><body><h1 class="top-card-layout__title">Tetrate</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cache, _ := NewResponseCache(dir)
+	client := NewScrapeClient(WithCache(cache))
+	for i := 0; i < 2; i++ {
+		company, err := GetCompanyFromUrl(client, server.URL+"/company/tetrate?trk=test", false)
+		if err != nil || company.Name != "Tetrate" {
+			t.Fatalf("Expected company Tetrate, but got %+v (%v)", company, err)
+		}
+	}
+	if hits != 1 {
+		t.Errorf("Expected 1 request with a cache, but got %d", hits)
+	}
+
+	server.Close()
+	offline, _ := NewResponseCache(dir)
+	offline.SetOffline(true)
+	client = NewScrapeClient(WithCache(offline))
+	if _, err := GetCompanyFromUrl(client, server.URL+"/company/tetrate", false); err != nil {
+		t.Errorf("Expected offline cache hit, but got %v", err)
+	}
+	if _, err := GetCompanyFromUrl(client, server.URL+"/company/other", false); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, but got %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package linkedin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ErrCheckpointMismatch is returned when a checkpoint file was written by a
+// run with another command or other search terms.
+var ErrCheckpointMismatch = errors.New("checkpoint belongs to another run")
+
+// Checkpoint records the result pages and URLs a run has completed, along
+// with their results, so an interrupted run can resume where it stopped. The
+// file is rewritten after every completed page or URL.
+type Checkpoint struct {
+	path string
+
+	mu    sync.Mutex
+	state checkpointState
+	done  map[string]checkpointEntry
+}
+
+type checkpointState struct {
+	Task      string              `json:"task"`
+	Searches  map[string][]string `json:"searches,omitempty"`
+	Completed []checkpointEntry   `json:"completed"`
+}
+
+// checkpointEntry is a completed result page or URL and the results it gave.
+// Found is set when the page gave more results than were saved, Total when
+// the page reported the number of results of its search, and IDs when the
+// page is compared with the next one.
+type checkpointEntry struct {
+	Key     string            `json:"key"`
+	Found   int               `json:"found,omitempty"`
+	Total   int               `json:"total,omitempty"`
+	IDs     []string          `json:"ids,omitempty"`
+	Results []json.RawMessage `json:"results,omitempty"`
+}
+
+// found returns the number of results the page or URL gave.
+func (e checkpointEntry) found() int {
+	if e.Found > len(e.Results) {
+		return e.Found
+	}
+	return len(e.Results)
+}
+
+// OpenCheckpoint opens the checkpoint of task at path, restoring the progress
+// saved there by a previous run. task identifies the run, so a checkpoint is
+// never resumed by a different one.
+func OpenCheckpoint(path, task string) (*Checkpoint, error) {
+	c := &Checkpoint{
+		path:  path,
+		state: checkpointState{Task: task},
+		done:  make(map[string]checkpointEntry),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+	var state checkpointState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", path, err)
+	}
+	if state.Task != task {
+		return nil, fmt.Errorf("%w: %s was written by %q", ErrCheckpointMismatch, path, state.Task)
+	}
+
+	c.state = state
+	for _, entry := range state.Completed {
+		c.done[entry.Key] = entry
+	}
+	return c, nil
+}
+
+// Path returns the file the checkpoint is saved to.
+func (c *Checkpoint) Path() string {
+	return c.path
+}
+
+// Len returns the number of completed result pages and URLs.
+func (c *Checkpoint) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.state.Completed)
+}
+
+// CheckpointResults returns the results saved in c, in the order they were
+// completed.
+func CheckpointResults[T any](c *Checkpoint) ([]T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var results []T
+	for _, entry := range c.state.Completed {
+		for _, raw := range entry.Results {
+			var result T
+			if err := json.Unmarshal(raw, &result); err != nil {
+				return nil, fmt.Errorf("failed to read checkpoint %s: %w", c.path, err)
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// completed reports whether key was completed and how many results it gave.
+func (c *Checkpoint) completed(key string) (int, bool) {
+	entry, ok := c.completedPage(key)
+	return entry.found(), ok
+}
+
+// completedPage returns the entry of key, if it was completed.
+func (c *Checkpoint) completedPage(key string) (checkpointEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.done[key]
+	return entry, ok
+}
+
+// complete marks key as completed with results and saves the checkpoint.
+func (c *Checkpoint) complete(key string, results ...any) error {
+	return c.completePage(checkpointEntry{Key: key}, results...)
+}
+
+// completePage is like complete, but for a result page described by entry,
+// which gave entry.Found results of which only results are saved, e.g.
+// because the others were duplicates.
+func (c *Checkpoint) completePage(entry checkpointEntry, results ...any) error {
+	key := entry.Key
+	if entry.Found == len(results) {
+		entry.Found = 0
+	}
+	for _, result := range results {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to write checkpoint: %w", err)
+		}
+		entry.Results = append(entry.Results, raw)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.done[key]; ok {
+		return nil
+	}
+	c.state.Completed = append(c.state.Completed, entry)
+	c.done[key] = entry
+	return c.save()
+}
+
+// searchURLs returns the search engine results saved under key, so a resumed
+// run walks the same URLs as the interrupted one.
+func (c *Checkpoint) searchURLs(key string) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	urls, ok := c.state.Searches[key]
+	return urls, ok
+}
+
+func (c *Checkpoint) setSearchURLs(key string, urls []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state.Searches == nil {
+		c.state.Searches = make(map[string][]string)
+	}
+	c.state.Searches[key] = urls
+	return c.save()
+}
+
+func (c *Checkpoint) save() error {
+	data, err := json.MarshalIndent(c.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(c.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
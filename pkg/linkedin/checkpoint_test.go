@@ -0,0 +1,99 @@
+package linkedin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	checkpoint, err := OpenCheckpoint(path, "company search --keywords=[acme]")
+	if err != nil {
+		t.Fatalf("Expected no error opening a new checkpoint, but got %v", err)
+	}
+	if err := checkpoint.complete("https://www.linkedin.com/company/a", &Company{Name: "a"}); err != nil {
+		t.Fatalf("Expected no error completing a URL, but got %v", err)
+	}
+	if err := checkpoint.complete("https://www.linkedin.com/jobs/search?start=975"); err != nil {
+		t.Fatalf("Expected no error completing a page without results, but got %v", err)
+	}
+	if err := checkpoint.setSearchURLs("google:acme", []string{"https://www.linkedin.com/company/a"}); err != nil {
+		t.Fatalf("Expected no error saving search results, but got %v", err)
+	}
+
+	resumed, err := OpenCheckpoint(path, "company search --keywords=[acme]")
+	if err != nil {
+		t.Fatalf("Expected no error resuming the checkpoint, but got %v", err)
+	}
+	if resumed.Len() != 2 {
+		t.Errorf("Expected 2 completed entries, but got %d", resumed.Len())
+	}
+	if n, done := resumed.completed("https://www.linkedin.com/company/a"); !done || n != 1 {
+		t.Errorf("Expected the company URL completed with 1 result, but got %v with %d", done, n)
+	}
+	if n, done := resumed.completed("https://www.linkedin.com/jobs/search?start=975"); !done || n != 0 {
+		t.Errorf("Expected the empty page completed with 0 results, but got %v with %d", done, n)
+	}
+	if urls, ok := resumed.searchURLs("google:acme"); !ok || len(urls) != 1 {
+		t.Errorf("Expected the saved search results, but got %v", urls)
+	}
+	companies, err := CheckpointResults[*Company](resumed)
+	if err != nil || len(companies) != 1 || companies[0].Name != "a" {
+		t.Errorf("Expected the saved company a, but got %v (%v)", companies, err)
+	}
+
+	if _, err := OpenCheckpoint(path, "company search --keywords=[other]"); !errors.Is(err, ErrCheckpointMismatch) {
+		t.Errorf("Expected ErrCheckpointMismatch for another run, but got %v", err)
+	}
+}
+
+func TestStreamAllCheckpoint(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		name := strings.TrimPrefix(r.URL.Path, "/company/")
+		fmt.Fprintf(w, `<html><body><h1 class="top-card-layout__title">%s</h1></body></html>`, name)
+	}))
+	defer server.Close()
+
+	var urls []string
+	for i := 0; i < 4; i++ {
+		urls = append(urls, fmt.Sprintf("%s/company/c%d", server.URL, i))
+	}
+
+	checkpoint, err := OpenCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"), "test")
+	if err != nil {
+		t.Fatalf("Expected no error opening a new checkpoint, but got %v", err)
+	}
+	if err := checkpoint.complete(urls[1], &Company{Name: "c1"}); err != nil {
+		t.Fatalf("Expected no error completing a URL, but got %v", err)
+	}
+
+	client := NewScrapeClient(WithCheckpoint(checkpoint))
+	companies, err := collectCompanies(client, urls)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if requests != 3 {
+		t.Errorf("Expected 3 requests, skipping the completed URL, but got %d", requests)
+	}
+	if len(companies) != 3 {
+		t.Errorf("Expected 3 fetched companies, but got %d", len(companies))
+	}
+	saved, _ := CheckpointResults[*Company](checkpoint)
+	var names []string
+	for _, company := range saved {
+		names = append(names, company.Name)
+	}
+	if strings.Join(names, ",") != "c1,c0,c2,c3" {
+		t.Errorf("Expected the checkpoint to hold c1,c0,c2,c3, but got %v", names)
+	}
+}
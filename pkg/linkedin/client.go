@@ -1,77 +1,551 @@
 package linkedin
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math/rand"
 	"net/http"
-	"net/url"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/corpix/uarand"
+)
+
+const (
+	defaultTimeout     = 30 * time.Second
+	defaultRetries     = 3
+	defaultBurst       = 1
+	defaultConcurrency = 1
 )
 
 type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// ScrapeClient is the single HTTP client used for every LinkedIn and search
+// engine request. It applies the configured rate, user agents, proxies and
+// retries, and reuses connections across calls.
 type ScrapeClient struct {
-	client     *http.Client
-	rate       time.Duration
-	userAgents []string
-	proxies    []string
+	client        *http.Client
+	limiter       *HostLimiter
+	rate          time.Duration
+	burst         int
+	concurrency   int
+	userAgents    []string
+	profile       *HeaderProfile
+	headers       http.Header
+	proxies       []string
+	proxyStrategy ProxyStrategy
+	proxyPool     *ProxyPool
+	retries       int
+	maxBackoff    time.Duration
+	retryPolicy   RetryPolicy
+	attemptHook   func(Attempt)
+	breaker       *CircuitBreaker
+	cache         *ResponseCache
+	checkpoint    *Checkpoint
+	searchEngines []SearchEngine
+	ledger        *RequestLedger
+	recorder      *HARRecorder
+	replayer      *HARReplayer
+	robots        *RobotsPolicy
+	debug         bool
+
+	mu         sync.Mutex
+	disallowed []string
+}
+
+// ClientOption configures a ScrapeClient.
+type ClientOption func(*ScrapeClient)
+
+// WithTimeout sets the timeout of a single HTTP request.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *ScrapeClient) {
+		c.client.Timeout = timeout
+	}
+}
+
+// WithRate sets the base wait duration between two requests to the same host.
+func WithRate(rate time.Duration) ClientOption {
+	return func(c *ScrapeClient) {
+		c.rate = rate
+	}
+}
+
+// WithBurst sets how many requests to the same host may be sent back to back
+// before the rate applies.
+func WithBurst(burst int) ClientOption {
+	return func(c *ScrapeClient) {
+		c.burst = burst
+	}
+}
+
+// WithConcurrency sets how many pages a search fetches at once. The rate
+// limiter still paces every single request.
+func WithConcurrency(concurrency int) ClientOption {
+	return func(c *ScrapeClient) {
+		c.concurrency = concurrency
+	}
+}
+
+// WithLimiter sets the limiter used to pace requests, replacing the one
+// built from the rate and burst options. This allows several clients to share
+// one budget per host.
+func WithLimiter(limiter *HostLimiter) ClientOption {
+	return func(c *ScrapeClient) {
+		c.limiter = limiter
+	}
+}
+
+// WithUserAgents sets the user agents to rotate through, replacing the
+// User-Agent of the header profile on every request.
+func WithUserAgents(userAgents []string) ClientOption {
+	return func(c *ScrapeClient) {
+		c.userAgents = userAgents
+	}
+}
+
+// WithHeaderProfile sets the browser header profile sent with every request.
+// When none is set, one of the built-in profiles is picked for the lifetime
+// of the client.
+func WithHeaderProfile(profile *HeaderProfile) ClientOption {
+	return func(c *ScrapeClient) {
+		c.profile = profile
+	}
 }
 
-func NewScrapeClient(rate time.Duration, userAgents []string, timeout time.Duration) *ScrapeClient {
-	if len(userAgents) == 0 {
-		log.Fatal("user agents list cannot be empty")
+// WithHeaders sets headers sent with every request, overriding those of the
+// header profile.
+func WithHeaders(headers http.Header) ClientOption {
+	return func(c *ScrapeClient) {
+		c.headers = headers
 	}
+}
+
+// WithProxies sets the proxy URLs to rotate through.
+func WithProxies(proxies []string) ClientOption {
+	return func(c *ScrapeClient) {
+		c.proxies = proxies
+	}
+}
+
+// WithProxyStrategy sets how the next proxy is selected. The default is
+// RoundRobin.
+func WithProxyStrategy(strategy ProxyStrategy) ClientOption {
+	return func(c *ScrapeClient) {
+		c.proxyStrategy = strategy
+	}
+}
+
+// WithProxyPool sets the proxy pool to send requests through, replacing the
+// one built from the proxies and proxy strategy options.
+func WithProxyPool(pool *ProxyPool) ClientOption {
+	return func(c *ScrapeClient) {
+		c.proxyPool = pool
+	}
+}
+
+// WithRetries sets the maximum number of attempts per request.
+func WithRetries(retries int) ClientOption {
+	return func(c *ScrapeClient) {
+		c.retries = retries
+	}
+}
 
-	return &ScrapeClient{
-		client:     &http.Client{Timeout: timeout},
-		rate:       rate,
-		userAgents: userAgents,
+// WithMaxBackoff caps the wait between two attempts of the default retry
+// policy.
+func WithMaxBackoff(maxBackoff time.Duration) ClientOption {
+	return func(c *ScrapeClient) {
+		c.maxBackoff = maxBackoff
 	}
 }
 
+// WithRetryPolicy replaces the default exponential backoff retry policy.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *ScrapeClient) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithAttemptHook registers a function called after every attempt of a
+// request, which allows collecting a per-request attempt log.
+func WithAttemptHook(hook func(Attempt)) ClientOption {
+	return func(c *ScrapeClient) {
+		c.attemptHook = hook
+	}
+}
+
+// WithCircuitBreaker sets the circuit breaker guarding every LinkedIn fetch.
+func WithCircuitBreaker(breaker *CircuitBreaker) ClientOption {
+	return func(c *ScrapeClient) {
+		c.breaker = breaker
+	}
+}
+
+// WithCache sets the response cache LinkedIn pages and search engine results
+// are served from.
+func WithCache(cache *ResponseCache) ClientOption {
+	return func(c *ScrapeClient) {
+		c.cache = cache
+	}
+}
+
+// WithSearchEngines sets the search engines LinkedIn URLs are found with. An
+// engine that fails or finds nothing falls back to the next one. The default
+// is Google only.
+func WithSearchEngines(engines ...SearchEngine) ClientOption {
+	return func(c *ScrapeClient) {
+		c.searchEngines = engines
+	}
+}
+
+// WithCheckpoint skips the result pages and URLs completed in checkpoint,
+// and records the ones completed from now on.
+func WithCheckpoint(checkpoint *Checkpoint) ClientOption {
+	return func(c *ScrapeClient) {
+		c.checkpoint = checkpoint
+	}
+}
+
+// WithRequestLedger counts every request sent over the network in ledger,
+// and fails requests that would exceed its budgets with a *BudgetError.
+func WithRequestLedger(ledger *RequestLedger) ClientOption {
+	return func(c *ScrapeClient) {
+		c.ledger = ledger
+	}
+}
+
+// WithHARRecorder records every request of the client, including search
+// engine requests, into a HAR file.
+func WithHARRecorder(recorder *HARRecorder) ClientOption {
+	return func(c *ScrapeClient) {
+		c.recorder = recorder
+	}
+}
+
+// WithHARReplayer serves every request of the client from a HAR file instead
+// of the network. Proxies are not used while replaying.
+func WithHARReplayer(replayer *HARReplayer) ClientOption {
+	return func(c *ScrapeClient) {
+		c.replayer = replayer
+	}
+}
+
+// WithRobotsPolicy checks every URL against robots.txt before fetching it,
+// and paces requests to at least its Crawl-delay.
+func WithRobotsPolicy(policy *RobotsPolicy) ClientOption {
+	return func(c *ScrapeClient) {
+		c.robots = policy
+	}
+}
+
+// WithCookieJar sets the cookie jar of the client, which turns the otherwise
+// anonymous client into a session.
+func WithCookieJar(jar http.CookieJar) ClientOption {
+	return func(c *ScrapeClient) {
+		c.client.Jar = jar
+	}
+}
+
+// WithDebug enables request logging.
+func WithDebug(debug bool) ClientOption {
+	return func(c *ScrapeClient) {
+		c.debug = debug
+	}
+}
+
+// NewScrapeClient creates a ScrapeClient with the given options applied on top
+// of the defaults.
+func NewScrapeClient(opts ...ClientOption) *ScrapeClient {
+	c := &ScrapeClient{
+		client:        &http.Client{Timeout: defaultTimeout},
+		burst:         defaultBurst,
+		concurrency:   defaultConcurrency,
+		retries:       defaultRetries,
+		maxBackoff:    defaultMaxBackoff,
+		proxyStrategy: RoundRobin,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.limiter == nil {
+		c.limiter = NewHostLimiter(c.rate, c.burst)
+	}
+	if c.concurrency < 1 {
+		c.concurrency = defaultConcurrency
+	}
+	if c.retryPolicy == nil {
+		c.retryPolicy = NewExponentialBackoff(c.maxBackoff)
+	}
+	if len(c.searchEngines) == 0 {
+		c.searchEngines = []SearchEngine{GoogleEngine{}}
+	}
+	if c.profile == nil {
+		c.profile = randomHeaderProfile()
+	}
+	if c.debug {
+		log.Printf("using header profile %s", c.profile.Name)
+	}
+	c.setupTransport()
+	c.applyRobotsPolicy()
+	return c
+}
+
+// Limiter returns the limiter pacing the requests of the client.
+func (c *ScrapeClient) Limiter() *HostLimiter {
+	return c.limiter
+}
+
+// Close writes the HAR file of the client, if it records one. The client must
+// not be used afterwards.
+func (c *ScrapeClient) Close() error {
+	if c.recorder == nil {
+		return nil
+	}
+	return c.recorder.Close()
+}
+
+// SetProxies replaces the proxies of the client. It must not be called while
+// requests are in flight.
 func (c *ScrapeClient) SetProxies(proxies []string) {
 	c.proxies = proxies
+	c.proxyPool = nil
+	c.setupTransport()
+}
+
+// SetRobotsPolicy replaces the robots.txt policy of the client, e.g. with one
+// fetched through the client itself. It must not be called while requests
+// are in flight.
+func (c *ScrapeClient) SetRobotsPolicy(policy *RobotsPolicy) {
+	c.robots = policy
+	c.applyRobotsPolicy()
+}
+
+// Disallowed returns the URLs skipped so far because robots.txt disallows
+// them.
+func (c *ScrapeClient) Disallowed() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.disallowed...)
+}
+
+// applyRobotsPolicy slows the host of the robots.txt policy down to its
+// Crawl-delay, unless it is already paced slower.
+func (c *ScrapeClient) applyRobotsPolicy() {
+	if c.robots == nil {
+		return
+	}
+	delay := c.robots.CrawlDelay()
+	if delay > c.limiter.Interval(c.robots.host) {
+		c.limiter.SetHostRate(c.robots.host, delay, 1)
+	}
+}
+
+// checkRobots returns an error wrapping ErrDisallowed when robots.txt does
+// not allow fetching req, and records the URL as skipped.
+func (c *ScrapeClient) checkRobots(req *http.Request) error {
+	if c.robots == nil || c.robots.Allowed(req.URL) {
+		return nil
+	}
+	c.mu.Lock()
+	c.disallowed = append(c.disallowed, req.URL.String())
+	c.mu.Unlock()
+	if c.debug {
+		log.Printf("skipping %s: %v", req.URL.String(), ErrDisallowed)
+	}
+	return fmt.Errorf("%w: %s", ErrDisallowed, req.URL.String())
+}
+
+// setupTransport layers the transport of the client: the HAR replayer
+// replaces the network, otherwise requests go through the proxy pool, if any,
+// and the HAR recorder wraps whichever of the two is used.
+func (c *ScrapeClient) setupTransport() {
+	if c.proxyPool == nil && len(c.proxies) > 0 {
+		pool, err := NewProxyPool(c.proxies, c.proxyStrategy)
+		if err != nil {
+			log.Fatalf("invalid proxies: %v", err)
+		}
+		c.proxyPool = pool
+	}
+
+	var transport http.RoundTripper
+	switch {
+	case c.replayer != nil:
+		transport = c.replayer
+	case c.proxyPool != nil:
+		transport = c.proxyPool
+	}
+	if c.recorder != nil {
+		c.recorder.next = transport
+		transport = c.recorder
+	}
+	c.client.Transport = transport
 }
 
 func (c *ScrapeClient) Do(req *http.Request) (*http.Response, error) {
-	c.waitRandomDuration()
-	c.setRandomProxy(req)
-	req.Header.Set("User-Agent", c.randomUserAgent())
-	return c.client.Do(req)
+	if c.debug {
+		log.Printf("sending request to %s", req.URL.String())
+	}
+	if err := c.wait(req); err != nil {
+		return nil, err
+	}
+	// Replayed requests never reach the network, so they are free.
+	if c.ledger != nil && c.replayer == nil {
+		if err := c.ledger.take(req.URL.Hostname()); err != nil {
+			return nil, err
+		}
+	}
+	c.setHeaders(req)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := decompressBody(resp); err != nil {
+		return nil, err
+	}
+	c.adapt(req, resp)
+	return resp, nil
+}
+
+// Get fetches the given url, retrying up to the configured number of attempts.
+func (c *ScrapeClient) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := newRequest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return c.DoWithRetry(req, c.retries)
+}
+
+// Profile returns the header profile of the client.
+func (c *ScrapeClient) Profile() *HeaderProfile {
+	return c.profile
 }
 
+// Checkpoint returns the checkpoint of the client, or nil when it has none.
+func (c *ScrapeClient) Checkpoint() *Checkpoint {
+	return c.checkpoint
+}
+
+// setHeaders sets the headers of the header profile on req, unless req sets
+// them itself, followed by the header overrides.
+func (c *ScrapeClient) setHeaders(req *http.Request) {
+	c.profile.apply(req.Header)
+	if len(c.userAgents) > 0 || req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.randomUserAgent())
+	}
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	for name, values := range c.headers {
+		req.Header[http.CanonicalHeaderKey(name)] = values
+	}
+}
+
+// randomUserAgent returns one of the configured user agents, or a random one
+// when the header profile has no User-Agent either.
 func (c *ScrapeClient) randomUserAgent() string {
+	if len(c.userAgents) == 0 {
+		if ua := c.profile.UserAgent(); ua != "" {
+			return ua
+		}
+		return uarand.GetRandom()
+	}
 	randIndex := rand.Intn(len(c.userAgents))
 	return c.userAgents[randIndex]
 }
 
+// DoWithRetry sends req up to maxRetries times, as long as the retry policy
+// of the client asks for another attempt. Every attempt is sent as a fresh
+// clone of req, so requests with a body are replayed through GetBody.
 func (c *ScrapeClient) DoWithRetry(req *http.Request, maxRetries int) (*http.Response, error) {
 	if maxRetries <= 0 {
 		return nil, errors.New("maxRetries should be greater than 0")
 	}
 
-	var resp *http.Response
-	var err error
-	for i := 0; i < maxRetries; i++ {
-		resp, err = c.Do(req)
-		if err == nil {
+	var attempts []Attempt
+	for i := 1; ; i++ {
+		attemptReq, err := cloneRequest(req, i)
+		if err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		resp, err := c.Do(attemptReq)
+		if req.Context().Err() != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, req.Context().Err()
+		}
+
+		attempt := Attempt{Number: i, URL: req.URL.String(), Err: err, Duration: time.Since(start)}
+		if resp != nil {
+			attempt.StatusCode = resp.StatusCode
+		}
+		wait, retry := c.retryPolicy.Retry(i, resp, err)
+		if retry && i < maxRetries {
+			attempt.Wait = wait
+		}
+		attempts = append(attempts, attempt)
+		c.logAttempt(attempt)
+
+		if !retry || i >= maxRetries {
+			if err != nil {
+				return nil, &RetryError{Attempts: attempts, Err: err}
+			}
 			return resp, nil
 		}
-		log.Printf("attempt %d: Error sending request to %s: %v", i+1, req.URL.String(), err)
-		time.Sleep(time.Second * time.Duration(i+1)) // exponential backoff
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if err := sleepContext(req.Context(), wait); err != nil {
+			return nil, err
+		}
 	}
-	return nil, fmt.Errorf("failed after %d retries: %v", maxRetries, err)
 }
 
+// cloneRequest returns a copy of req for the given attempt with a fresh body.
+func cloneRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 || req.Body == nil || req.Body == http.NoBody {
+		return req.Clone(req.Context()), nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("cannot retry request to %s: body cannot be replayed", req.URL.String())
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+func (c *ScrapeClient) logAttempt(attempt Attempt) {
+	if c.attemptHook != nil {
+		c.attemptHook(attempt)
+	}
+	if c.debug || attempt.Err != nil {
+		log.Print(attempt.String())
+	}
+}
+
+// DoDebug is like Do, but checks req against robots.txt first and logs the
+// request and its outcome.
 func (c *ScrapeClient) DoDebug(req *http.Request) (*http.Response, error) {
 	log.Printf("Sending request to %s\n", req.URL.String())
-	c.waitRandomDuration()
-	c.setRandomProxy(req)
-	resp, err := c.client.Do(req)
+	if err := c.checkRobots(req); err != nil {
+		log.Printf("request to %s failed: %v", req.URL.String(), err)
+		return nil, err
+	}
+	resp, err := c.Do(req)
 	if err != nil {
 		log.Printf("request to %s failed: %v", req.URL.String(), err)
 		return nil, err
@@ -80,25 +554,133 @@ func (c *ScrapeClient) DoDebug(req *http.Request) (*http.Response, error) {
 	return resp, nil
 }
 
-func (c *ScrapeClient) waitRandomDuration() {
-	randomDuration := c.rate + time.Duration(rand.Int63n(int64(c.rate)))
-	log.Printf("waiting for %v before sending request", randomDuration)
-	time.Sleep(randomDuration)
+// fetchDocument sends req and parses a successful response as HTML. Pages
+// are served from the response cache when one is configured.
+func (c *ScrapeClient) fetchDocument(req *http.Request) (*goquery.Document, error) {
+	if c.cache == nil {
+		doc, _, err := c.guardedFetchPage(req)
+		return doc, err
+	}
+
+	body, err := c.cache.fetch(normalizeURL(req.URL), entityTypeOf(req.URL), func() ([]byte, error) {
+		_, body, err := c.guardedFetchPage(req)
+		return body, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	return doc, nil
+}
+
+// guardedFetchPage fetches req if robots.txt allows it, through the circuit
+// breaker, if any.
+func (c *ScrapeClient) guardedFetchPage(req *http.Request) (*goquery.Document, []byte, error) {
+	if err := c.checkRobots(req); err != nil {
+		return nil, nil, err
+	}
+	if c.breaker == nil {
+		return c.fetchPage(req)
+	}
+	probe, err := c.breaker.Allow(req.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, body, err := c.fetchPage(req)
+	c.breaker.Record(probe, err)
+	return doc, body, err
 }
 
-func (c *ScrapeClient) setRandomProxy(req *http.Request) {
-	if len(c.proxies) > 0 {
-		randIndex := rand.Intn(len(c.proxies))
-		proxyURL, err := url.Parse(c.proxies[randIndex])
-		if err != nil {
-			log.Printf("failed to parse proxy URL %s: %v", c.proxies[randIndex], err)
-			return
+// fetchPage sends req and returns both the parsed and the UTF-8 body of a
+// successful response that is neither an auth wall nor a captcha.
+func (c *ScrapeClient) fetchPage(req *http.Request) (*goquery.Document, []byte, error) {
+	resp, err := c.DoWithRetry(req, c.retries)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch %s: %w", req.URL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	// Check for non-2xx status codes
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, nil, newHTTPError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", req.URL.String(), err)
+	}
+	body, err = toUTF8(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", req.URL.String(), err)
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	if err := detectBlockPage(resp, doc); err != nil {
+		c.limiter.Backoff(req.URL.Host)
+		return nil, nil, err
+	}
+	return doc, body, nil
+}
+
+// newRequest creates a GET request for url. The headers shared by all
+// fetches are set when the request is sent.
+func newRequest(ctx context.Context, url string) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, "GET", url, nil)
+}
+
+// wait blocks until the limiter allows a request to the host of req.
+func (c *ScrapeClient) wait(req *http.Request) error {
+	if c.debug {
+		log.Printf("waiting for a %v slot on %s before sending request", c.limiter.Interval(req.URL.Host), req.URL.Host)
+	}
+	return c.limiter.Wait(req.Context(), req.URL.Host)
+}
+
+// adapt slows the host of req down when resp signals blocking, and speeds it
+// up again otherwise.
+func (c *ScrapeClient) adapt(req *http.Request, resp *http.Response) {
+	if isBlockResponse(resp) {
+		c.limiter.Backoff(req.URL.Host)
+		if c.debug {
+			log.Printf("%s is blocking, slowing down to one request per %v", req.URL.Host, c.limiter.Interval(req.URL.Host))
 		}
-		c.client.Transport = &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
+		return
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		c.limiter.Recover(req.URL.Host)
+	}
+}
+
+// isBlockResponse reports whether resp is a rate limit or an auth wall.
+func isBlockResponse(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, statusLinkedInBlocked:
+		return true
+	}
+	if resp.Request == nil {
+		return false
+	}
+	for _, path := range authWallPaths {
+		if strings.HasPrefix(resp.Request.URL.Path, path) {
+			return true
 		}
-	} else {
-		// Use default transport if no proxies are set
-		c.client.Transport = http.DefaultTransport
+	}
+	return false
+}
+
+// sleepContext pauses for d, or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
 }
@@ -1,28 +1,26 @@
 package linkedin
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
-	"time"
-
-	"github.com/PuerkitoBio/goquery"
-	"github.com/corpix/uarand"
 )
 
 // Company represents the structure of a LinkedIn company.
 type Company struct {
-	FollowerCount int    `json:"followerCount"  csv:"followerCount"`
-	FoundedOn     string `json:"foundedOn"      csv:"foundedOn"`
-	Headline      string `json:"headline"       csv:"headline"`
-	Headquarters  string `json:"headquarters"   csv:"headquarters"`
-	Industry      string `json:"industry"       csv:"industry"`
-	Name          string `json:"name"           csv:"name"`
-	Size          string `json:"size"           csv:"size"`
-	Specialties   string `json:"specialties"    csv:"specialties"`
-	Type          string `json:"type"           csv:"type"`
-	Website       string `json:"website"        csv:"website"`
+	FollowerCount int          `json:"followerCount"     csv:"followerCount"`
+	FoundedOn     string       `json:"foundedOn"         csv:"foundedOn"`
+	Headline      string       `json:"headline"          csv:"headline"`
+	Headquarters  string       `json:"headquarters"      csv:"headquarters"`
+	Industry      string       `json:"industry"          csv:"industry"`
+	Name          string       `json:"name"              csv:"name"`
+	Size          string       `json:"size"              csv:"size"`
+	Specialties   string       `json:"specialties"       csv:"specialties"`
+	Type          string       `json:"type"              csv:"type"`
+	Website       string       `json:"website"           csv:"website"`
+	Sources       FieldSources `json:"sources,omitempty" csv:"-"`
 }
 
 func (c *Company) CsvContent() string {
@@ -56,84 +54,80 @@ func (cs Companies) Get(i int) Serializable {
 	return Serializable(cs[i])
 }
 
-func SearchCompaniesOnline(keywords []string, interval time.Duration, debug bool) (Companies, error) {
-	var companies Companies
-	urls, err := GoogleGetLinkedInCompanyURLs(keywords, interval, debug)
-	if err != nil {
-		return nil, fmt.Errorf("error fetching LinkedIn company URLs: %v", err)
-	}
+func SearchCompaniesOnline(client *ScrapeClient, keywords []string, debug bool) (Companies, error) {
+	return SearchCompaniesOnlineContext(context.Background(), client, keywords, debug)
+}
 
-	var errs []string
-	for _, url := range urls {
-		company, err := GetCompanyFromUrl(url, debug)
-		if err != nil {
-			errs = append(errs, fmt.Sprintf("error fetching company from URL %s: %v", url, err))
-			continue
-		}
-		companies = append(companies, company)
-	}
+// SearchCompaniesOnlineContext is like SearchCompaniesOnline, but stops
+// fetching when ctx is done and returns the companies fetched so far along with
+// ctx.Err().
+func SearchCompaniesOnlineContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool) (Companies, error) {
+	return collect(func(yield func(*Company, error) bool) error {
+		return StreamCompaniesOnlineContext(ctx, client, keywords, debug, yield)
+	})
+}
+
+func StreamCompaniesOnline(client *ScrapeClient, keywords []string, debug bool, yield func(*Company, error) bool) error {
+	return StreamCompaniesOnlineContext(context.Background(), client, keywords, debug, yield)
+}
 
-	if len(errs) > 0 {
-		return companies, fmt.Errorf("encountered errors: %s", strings.Join(errs, "; "))
+// StreamCompaniesOnlineContext is like SearchCompaniesOnlineContext, but passes
+// every company to yield as soon as it is fetched, in search result order,
+// instead of returning them all at the end. Errors fetching a single company
+// are passed to yield as well. Returning false from yield stops the search.
+func StreamCompaniesOnlineContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool, yield func(*Company, error) bool) error {
+	urls, err := searchLinkedInURLs(ctx, client, linkedInCompanySite, keywords, debug)
+	if err != nil {
+		return fmt.Errorf("error fetching LinkedIn company URLs: %w", err)
 	}
 
-	return companies, nil
+	return streamAll(ctx, client, urls, "company", func(ctx context.Context, url string) (*Company, error) {
+		return GetCompanyFromUrlContext(ctx, client, url, debug)
+	}, yield)
+}
+
+func GetCompanyFromUrl(client *ScrapeClient, url string, debug bool) (*Company, error) {
+	return GetCompanyFromUrlContext(context.Background(), client, url, debug)
 }
 
-func GetCompanyFromUrl(url string, debug bool) (*Company, error) {
+// GetCompanyFromUrlContext is like GetCompanyFromUrl, but aborts the request
+// when ctx is done.
+func GetCompanyFromUrlContext(ctx context.Context, client *ScrapeClient, url string, debug bool) (*Company, error) {
 	if debug {
 		fmt.Printf("going to fetch company from url %v", url)
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := newRequest(ctx, url)
 	if err != nil {
 		return &Company{}, err
 	}
-	req.Header.Set("Accept-Encoding", "identity")
-	req.Header.Set("User-Agent", uarand.GetRandom())
 
-	company, err := getCompanyFromRequest(req, debug)
-	if err != nil {
-		if httpErr, ok := err.(*HTTPError); ok && httpErr.StatusCode == http.StatusTooManyRequests {
-			return &Company{}, err
-		}
-		return &Company{}, err
-	}
-	return company, nil
+	return getCompanyFromRequest(client, req, debug)
 }
 
-func getCompanyFromRequest(req *http.Request, debug bool) (*Company, error) {
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return &Company{}, fmt.Errorf("failed to fetch LinkedIn company: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check for non-2xx status codes
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return &Company{}, &HTTPError{
-			StatusCode: resp.StatusCode,
-			Message:    fmt.Sprintf("received non-2xx response: %d %s", resp.StatusCode, resp.Status),
-		}
-	}
-
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+func getCompanyFromRequest(client *ScrapeClient, req *http.Request, debug bool) (*Company, error) {
+	doc, err := client.fetchDocument(req)
 	if err != nil {
-		return &Company{}, fmt.Errorf("failed to parse HTML: %w", err)
+		return &Company{}, err
 	}
 
 	var company Company
-	followerCount, _ := extractFollowersCount(strings.TrimSpace(doc.Find(".top-card-layout__first-subline").Text()))
-	foundedOn := strings.TrimSpace(doc.Find("div[data-test-id='about-us__foundedOn'] dd").Text())
-	headline := strings.TrimSpace(doc.Find(".top-card-layout__second-subline").Text())
-	headquarters := strings.TrimSpace(doc.Find("div[data-test-id='about-us__headquarters'] dd").Text())
-	industry := strings.TrimSpace(doc.Find("div[data-test-id='about-us__industry'] dd").Text())
-	name := strings.TrimSpace(doc.Find(".top-card-layout__title").Text())
-	size := strings.TrimSpace(doc.Find("div[data-test-id='about-us__size'] dd").Text())
-	specialties := strings.TrimSpace(doc.Find("div[data-test-id='about-us__specialties'] dd").Text())
-	companyType := strings.TrimSpace(doc.Find("div[data-test-id='about-us__organizationType'] dd").Text())
-	website := strings.Split(strings.TrimSpace(doc.Find("div[data-test-id='about-us__website'] dd").Text()), "\n")[0]
+	data := parseStructuredData(doc)
+	org := data.object("Organization")
+	sources := make(FieldSources)
+
+	ogFollowerCount, _ := extractFollowersCount(data.og("description"))
+	cssFollowerCount, _ := extractFollowersCount(strings.TrimSpace(doc.Find(".top-card-layout__first-subline").Text()))
+	followerCount := pick(sources, "followerCount", fromOpenGraph(ogFollowerCount), fromCSS(cssFollowerCount))
+	foundedOn := pick(sources, "foundedOn", fromCSS(strings.TrimSpace(doc.Find("div[data-test-id='about-us__foundedOn'] dd").Text())))
+	headline := pick(sources, "headline", fromJSONLD(org.str("slogan")), fromCSS(strings.TrimSpace(doc.Find(".top-card-layout__second-subline").Text())))
+	headquarters := pick(sources, "headquarters", fromJSONLD(org.place("address")), fromCSS(strings.TrimSpace(doc.Find("div[data-test-id='about-us__headquarters'] dd").Text())))
+	industry := pick(sources, "industry", fromCSS(strings.TrimSpace(doc.Find("div[data-test-id='about-us__industry'] dd").Text())))
+	name := pick(sources, "name", fromJSONLD(org.str("name")), fromOpenGraph(strings.TrimSuffix(data.og("title"), " | LinkedIn")), fromCSS(strings.TrimSpace(doc.Find(".top-card-layout__title").Text())))
+	size := pick(sources, "size", fromCSS(strings.TrimSpace(doc.Find("div[data-test-id='about-us__size'] dd").Text())))
+	specialties := pick(sources, "specialties", fromCSS(strings.TrimSpace(doc.Find("div[data-test-id='about-us__specialties'] dd").Text())))
+	companyType := pick(sources, "type", fromCSS(strings.TrimSpace(doc.Find("div[data-test-id='about-us__organizationType'] dd").Text())))
+	website := pick(sources, "website", fromJSONLD(org.str("sameAs")), fromCSS(strings.Split(strings.TrimSpace(doc.Find("div[data-test-id='about-us__website'] dd").Text()), "\n")[0]))
 
 	company = Company{
 		FollowerCount: followerCount,
@@ -146,6 +140,7 @@ func getCompanyFromRequest(req *http.Request, debug bool) (*Company, error) {
 		Specialties:   specialties,
 		Type:          companyType,
 		Website:       website,
+		Sources:       sources,
 	}
 
 	// Print the company for testing
@@ -153,5 +148,8 @@ func getCompanyFromRequest(req *http.Request, debug bool) (*Company, error) {
 		log.Printf("Company: %+v", company)
 	}
 
+	if company.Name == "" {
+		return &company, &ParseError{URL: req.URL.String(), Entity: "company"}
+	}
 	return &company, nil
 }
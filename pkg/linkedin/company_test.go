@@ -229,7 +229,7 @@ func TestGetCompanyFromRequest(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Error creating HTTP request: %v", err)
 			}
-			company, err := getCompanyFromRequest(req, false)
+			company, err := getCompanyFromRequest(NewScrapeClient(), req, false)
 			if err != nil {
 				t.Fatalf("Error in getCompanyFromRequest for file %s: %s", tt.fileName, err)
 			}
@@ -0,0 +1,277 @@
+package linkedin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// netscapeHTTPOnlyPrefix marks HttpOnly cookies in a Netscape cookies.txt.
+const netscapeHTTPOnlyPrefix = "#HttpOnly_"
+
+// CookieJar is an http.CookieJar that can import browser cookie exports and
+// keeps its state in a file between runs. Cookie values are session secrets,
+// so they are never logged.
+type CookieJar struct {
+	path string
+
+	mu      sync.Mutex
+	jar     *cookiejar.Jar
+	cookies map[string]storedCookie
+}
+
+// storedCookie is a cookie in the JSON format of common browser cookie
+// export extensions, which is also the format the jar is saved in.
+type storedCookie struct {
+	Domain         string  `json:"domain"`
+	HostOnly       bool    `json:"hostOnly"`
+	Path           string  `json:"path"`
+	Name           string  `json:"name"`
+	Value          string  `json:"value"`
+	Secure         bool    `json:"secure"`
+	HTTPOnly       bool    `json:"httpOnly"`
+	Session        bool    `json:"session"`
+	ExpirationDate float64 `json:"expirationDate,omitempty"`
+}
+
+// NewCookieJar creates a CookieJar saved to path, restoring the cookies
+// saved there by a previous run. With an empty path the jar is kept in
+// memory only.
+func NewCookieJar(path string) (*CookieJar, error) {
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		return nil, err
+	}
+	j := &CookieJar{
+		path:    path,
+		jar:     jar,
+		cookies: make(map[string]storedCookie),
+	}
+	if path == "" {
+		return j, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cookie jar: %w", err)
+	}
+	cookies, err := parseJSONCookies(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cookie jar: %w", err)
+	}
+	j.add(cookies)
+	return j, nil
+}
+
+// ImportFile adds the cookies of a Netscape cookies.txt or a JSON cookie
+// export to the jar.
+func (j *CookieJar) ImportFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to open cookies file: %w", err)
+	}
+
+	var cookies []storedCookie
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		cookies, err = parseJSONCookies(trimmed)
+	} else {
+		cookies, err = parseNetscapeCookies(data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cookies file %s: %w", path, err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.add(cookies)
+	return j.save()
+}
+
+// SetCookies implements http.CookieJar.
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.jar.SetCookies(u, cookies)
+	for _, c := range cookies {
+		stored := storedCookie{
+			Domain:   strings.TrimPrefix(c.Domain, "."),
+			Path:     c.Path,
+			Name:     c.Name,
+			Value:    c.Value,
+			Secure:   c.Secure,
+			HTTPOnly: c.HttpOnly,
+		}
+		if stored.Domain == "" {
+			stored.Domain = u.Hostname()
+			stored.HostOnly = true
+		}
+		if stored.Path == "" {
+			stored.Path = "/"
+		}
+
+		switch {
+		case c.MaxAge < 0:
+			delete(j.cookies, stored.key())
+			continue
+		case c.MaxAge > 0:
+			stored.ExpirationDate = float64(time.Now().Add(time.Duration(c.MaxAge) * time.Second).Unix())
+		case !c.Expires.IsZero():
+			if c.Expires.Before(time.Now()) {
+				delete(j.cookies, stored.key())
+				continue
+			}
+			stored.ExpirationDate = float64(c.Expires.Unix())
+		default:
+			stored.Session = true
+		}
+		j.cookies[stored.key()] = stored
+	}
+
+	// A failure to persist the jar must not fail the request that set the
+	// cookies, and the cookies themselves are not worth logging.
+	if err := j.save(); err != nil {
+		log.Printf("failed to save cookie jar %s: %v", j.path, err)
+	}
+}
+
+// Cookies implements http.CookieJar.
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.jar.Cookies(u)
+}
+
+// Len returns the number of cookies in the jar.
+func (j *CookieJar) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.cookies)
+}
+
+// add stores cookies in both the jar and the saved state, skipping the
+// expired ones.
+func (j *CookieJar) add(cookies []storedCookie) {
+	now := time.Now()
+	for _, c := range cookies {
+		c.Domain = strings.TrimPrefix(c.Domain, ".")
+		if c.Path == "" {
+			c.Path = "/"
+		}
+		cookie := &http.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HTTPOnly,
+		}
+		if !c.HostOnly {
+			cookie.Domain = c.Domain
+		}
+		if !c.Session && c.ExpirationDate > 0 {
+			expires := time.Unix(int64(c.ExpirationDate), 0)
+			if expires.Before(now) {
+				continue
+			}
+			cookie.Expires = expires
+		}
+
+		scheme := "http"
+		if c.Secure {
+			scheme = "https"
+		}
+		j.jar.SetCookies(&url.URL{Scheme: scheme, Host: c.Domain, Path: c.Path}, []*http.Cookie{cookie})
+		j.cookies[c.key()] = c
+	}
+}
+
+// save writes the jar to its file, readable by the owner only.
+func (j *CookieJar) save() error {
+	if j.path == "" {
+		return nil
+	}
+
+	now := time.Now()
+	cookies := make([]storedCookie, 0, len(j.cookies))
+	for _, c := range j.cookies {
+		if !c.Session && c.ExpirationDate > 0 && time.Unix(int64(c.ExpirationDate), 0).Before(now) {
+			continue
+		}
+		cookies = append(cookies, c)
+	}
+	data, err := json.MarshalIndent(cookies, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(j.path, data, 0600)
+}
+
+func (c storedCookie) key() string {
+	return c.Domain + ";" + c.Path + ";" + c.Name
+}
+
+// parseJSONCookies parses a JSON array of cookies, or a single cookie.
+func parseJSONCookies(data []byte) ([]storedCookie, error) {
+	var cookies []storedCookie
+	if err := json.Unmarshal(data, &cookies); err == nil {
+		return cookies, nil
+	}
+	var cookie storedCookie
+	if err := json.Unmarshal(data, &cookie); err != nil {
+		return nil, err
+	}
+	return []storedCookie{cookie}, nil
+}
+
+// parseNetscapeCookies parses a Netscape cookies.txt, as exported by curl and
+// most browser extensions.
+func parseNetscapeCookies(data []byte) ([]storedCookie, error) {
+	var cookies []storedCookie
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		// Only trim line endings, as an empty value leaves a trailing tab.
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(line, netscapeHTTPOnlyPrefix)
+		line = strings.TrimPrefix(line, netscapeHTTPOnlyPrefix)
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: expected 7 tab separated fields, got %d", n, len(fields))
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry %s", n, fields[4])
+		}
+		cookies = append(cookies, storedCookie{
+			Domain:         fields[0],
+			HostOnly:       !strings.EqualFold(fields[1], "TRUE"),
+			Path:           fields[2],
+			Secure:         strings.EqualFold(fields[3], "TRUE"),
+			ExpirationDate: float64(expires),
+			Session:        expires == 0,
+			Name:           fields[5],
+			Value:          fields[6],
+			HTTPOnly:       httpOnly,
+		})
+	}
+	return cookies, scanner.Err()
+}
@@ -0,0 +1,110 @@
+package linkedin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseNetscapeCookies(t *testing.T) {
+	data := "# Netscape HTTP Cookie File\n" +
+		".linkedin.com\tTRUE\t/\tTRUE\t4102444800\tli_at\tsecret\n" +
+		"#HttpOnly_www.linkedin.com\tFALSE\t/\tTRUE\t0\tJSESSIONID\t\n"
+
+	cookies, err := parseNetscapeCookies([]byte(data))
+	if err != nil {
+		t.Fatalf("Error parsing cookies: %v", err)
+	}
+	if len(cookies) != 2 {
+		t.Fatalf("Expected 2 cookies, but got %d", len(cookies))
+	}
+	if cookies[0].Name != "li_at" || cookies[0].HostOnly || !cookies[0].Secure || cookies[0].Session {
+		t.Errorf("Expected a secure domain cookie li_at, but got %+v", cookies[0])
+	}
+	if cookies[1].Name != "JSESSIONID" || !cookies[1].HostOnly || !cookies[1].HTTPOnly || !cookies[1].Session {
+		t.Errorf("Expected a host only HttpOnly session cookie JSESSIONID, but got %+v", cookies[1])
+	}
+
+	if _, err := parseNetscapeCookies([]byte("linkedin.com\tTRUE\t/\n")); err == nil {
+		t.Errorf("Expected an error for a line with missing fields")
+	}
+}
+
+func TestCookieJarImport(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"netscape", ".linkedin.com\tTRUE\t/\tTRUE\t4102444800\tli_at\tsecret\n"},
+		{"json", `[{"domain":".linkedin.com","path":"/","name":"li_at","value":"secret","secure":true,"expirationDate":4102444800}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cookies")
+			os.WriteFile(path, []byte(test.content), 0600)
+
+			jar, _ := NewCookieJar("")
+			if err := jar.ImportFile(path); err != nil {
+				t.Fatalf("Error importing cookies: %v", err)
+			}
+			u, _ := url.Parse("https://www.linkedin.com/in/someone")
+			cookies := jar.Cookies(u)
+			if len(cookies) != 1 || cookies[0].Name != "li_at" || cookies[0].Value != "secret" {
+				t.Errorf("Expected cookie li_at=secret, but got %v", cookies)
+			}
+		})
+	}
+}
+
+func TestCookieJarPersistence(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("bcookie"); err == nil {
+			received = c.Value
+		}
+		http.SetCookie(w, &http.Cookie{Name: "bcookie", Value: "v1", MaxAge: 3600})
+		w.Write([]byte(`<html><body><h1 class="top-card-layout__title">Tetrate</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "jar.json")
+	jar, _ := NewCookieJar(path)
+	client := NewScrapeClient(WithCookieJar(jar))
+	if _, err := GetCompanyFromUrl(client, server.URL+"/company/tetrate", false); err != nil {
+		t.Fatalf("Error fetching company: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected the cookie jar to be saved, but got %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected cookie jar permissions 0600, but got %v", info.Mode().Perm())
+	}
+
+	jar, err = NewCookieJar(path)
+	if err != nil {
+		t.Fatalf("Error restoring cookie jar: %v", err)
+	}
+	client = NewScrapeClient(WithCookieJar(jar))
+	if _, err := GetCompanyFromUrl(client, server.URL+"/company/tetrate", false); err != nil {
+		t.Fatalf("Error fetching company: %v", err)
+	}
+	if received != "v1" {
+		t.Errorf("Expected the restored cookie to be sent, but got %q", received)
+	}
+}
+
+func TestHARRedactsCookies(t *testing.T) {
+	headers := harHeaders(http.Header{"Cookie": {"li_at=secret"}, "Accept": {"text/html"}})
+	for _, h := range headers {
+		if strings.Contains(h.Value, "secret") {
+			t.Errorf("Expected header %s to be redacted, but got %s", h.Name, h.Value)
+		}
+	}
+}
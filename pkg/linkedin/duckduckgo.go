@@ -0,0 +1,88 @@
+package linkedin
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const duckDuckGoHost = "html.duckduckgo.com"
+
+// duckDuckGoRecency maps recencies to the values of the df parameter.
+var duckDuckGoRecency = map[Recency]string{
+	RecencyDay:   "d",
+	RecencyWeek:  "w",
+	RecencyMonth: "m",
+	RecencyYear:  "y",
+}
+
+// DuckDuckGoEngine searches DuckDuckGo through its HTML-only result pages.
+type DuckDuckGoEngine struct{}
+
+func (DuckDuckGoEngine) Name() string {
+	return "duckduckgo"
+}
+
+// Search scrapes the DuckDuckGo result pages of query, following the next
+// page form until searchMaxResults is reached.
+func (DuckDuckGoEngine) Search(ctx context.Context, client *ScrapeClient, query *Query) (SearchHits, error) {
+	return searchPages(ctx, client, duckDuckGoSearchURL(query), parseDuckDuckGoResults)
+}
+
+func duckDuckGoSearchURL(query *Query) string {
+	params := url.Values{}
+	params.Set("q", query.SearchTerms())
+	if recency, ok := duckDuckGoRecency[query.Posted()]; ok {
+		params.Set("df", recency)
+	}
+	return "https://" + duckDuckGoHost + "/html/?" + params.Encode()
+}
+
+// parseDuckDuckGoResults returns the hits of a DuckDuckGo result page and the
+// absolute URL of the next page, if any. The next page is a form that
+// browsers post, but its fields work as a query string too.
+func parseDuckDuckGoResults(doc *goquery.Document, pageURL *url.URL) (SearchHits, string) {
+	var hits SearchHits
+	doc.Find("div.result").Not(".result--ad").Each(func(i int, s *goquery.Selection) {
+		link := s.Find("a.result__a").First()
+		href, _ := link.Attr("href")
+		if target := duckDuckGoTarget(resolveURL(pageURL, href)); target != "" {
+			hits = append(hits, &SearchHit{
+				URL:     target,
+				Title:   cleanText(link),
+				Snippet: cleanText(s.Find(".result__snippet").First()),
+			})
+		}
+	})
+
+	var next string
+	doc.Find("div.nav-link form").EachWithBreak(func(i int, form *goquery.Selection) bool {
+		submit := form.Find("input[type=submit]").AttrOr("value", "")
+		if !strings.Contains(strings.ToLower(submit), "next") {
+			return true
+		}
+		params := url.Values{}
+		form.Find("input[type=hidden]").Each(func(i int, input *goquery.Selection) {
+			if name := input.AttrOr("name", ""); name != "" {
+				params.Add(name, input.AttrOr("value", ""))
+			}
+		})
+		if action := resolveURL(pageURL, form.AttrOr("action", "")); action != "" {
+			next = action + "?" + params.Encode()
+		}
+		return false
+	})
+	return hits, next
+}
+
+// duckDuckGoTarget returns the URL a DuckDuckGo redirect link leads to, which
+// is its uddg parameter. Other links are returned as is.
+func duckDuckGoTarget(href string) string {
+	u, err := url.Parse(href)
+	if err != nil || !strings.HasSuffix(u.Hostname(), "duckduckgo.com") || u.Path != "/l/" {
+		return href
+	}
+	return u.Query().Get("uddg")
+}
@@ -0,0 +1,129 @@
+package linkedin
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andybalholm/brotli"
+	"golang.org/x/net/html/charset"
+)
+
+// acceptEncoding is advertised on every request, as browsers do.
+const acceptEncoding = "gzip, deflate, br"
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// decompressBody replaces the body of resp with its decoded content, as
+// listed in the Content-Encoding header, and removes the headers that no
+// longer apply to it.
+func decompressBody(resp *http.Response) error {
+	header := resp.Header.Get("Content-Encoding")
+	if header == "" {
+		return nil
+	}
+
+	// Encodings are listed in the order they were applied, so undo them
+	// from last to first. An empty body, like the one of a 429 or a 204, is
+	// left empty, so the response keeps its status for the retries and the
+	// circuit breaker.
+	encodings := strings.Split(header, ",")
+	body := &decodedBody{Reader: resp.Body, closers: []io.Closer{resp.Body}}
+	for i := len(encodings) - 1; i >= 0; i-- {
+		encoding := strings.ToLower(strings.TrimSpace(encodings[i]))
+		switch encoding {
+		case "", "identity":
+			continue
+		case "gzip", "x-gzip":
+			reader, err := gzip.NewReader(body.Reader)
+			if err == io.EOF {
+				continue
+			}
+			if err != nil {
+				body.Close()
+				return fmt.Errorf("failed to decode gzip body: %w", err)
+			}
+			body.Reader = reader
+			body.closers = append(body.closers, reader)
+		case "deflate":
+			reader, err := newDeflateReader(body.Reader)
+			if err == io.EOF {
+				continue
+			}
+			if err != nil {
+				body.Close()
+				return fmt.Errorf("failed to decode deflate body: %w", err)
+			}
+			body.Reader = reader
+			body.closers = append(body.closers, reader)
+		case "br":
+			body.Reader = brotli.NewReader(body.Reader)
+		default:
+			body.Close()
+			return fmt.Errorf("unsupported content encoding: %s", encoding)
+		}
+	}
+
+	resp.Body = body
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// newDeflateReader decodes a deflate body. The standard says it is wrapped in
+// zlib, but some servers send raw deflate data instead. An empty body gives
+// io.EOF.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(2)
+	if err == io.EOF && len(header) > 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+// decodedBody closes the decoders along with the original body.
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (b *decodedBody) Close() error {
+	var err error
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		if closeErr := b.closers[i].Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// toUTF8 converts an HTML body to UTF-8, using the charset of the Content-Type
+// header, a byte order mark or a meta tag. Bodies without a declared charset
+// are assumed to be UTF-8 when they are valid UTF-8.
+func toUTF8(body []byte, contentType string) ([]byte, error) {
+	encoding, name, certain := charset.DetermineEncoding(body, contentType)
+	if name == "utf-8" || (!certain && utf8.Valid(body)) {
+		return bytes.TrimPrefix(body, utf8BOM), nil
+	}
+	decoded, err := encoding.NewDecoder().Bytes(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s body: %w", name, err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,157 @@
+package linkedin
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const encodingTestPage = `<html><body><h1 class="top-card-layout__title">Café</h1></body></html>`
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	default:
+		return data
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Error compressing: %v", err)
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestContentEncoding(t *testing.T) {
+	tests := []struct {
+		encoding string
+		header   string
+	}{
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"raw-deflate", "deflate"},
+		{"br", "br"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.encoding, func(t *testing.T) {
+			var accepted string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				accepted = r.Header.Get("Accept-Encoding")
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				if test.header != "" {
+					w.Header().Set("Content-Encoding", test.header)
+				}
+				w.Write(compress(t, test.encoding, []byte(encodingTestPage)))
+			}))
+			defer server.Close()
+
+			company, err := GetCompanyFromUrl(NewScrapeClient(), server.URL+"/company/cafe", false)
+			if err != nil {
+				t.Fatalf("Error fetching company: %v", err)
+			}
+			if company.Name != "Café" {
+				t.Errorf("Expected company name Café, but got %s", company.Name)
+			}
+			if accepted != acceptEncoding {
+				t.Errorf("Expected Accept-Encoding %q, but got %q", acceptEncoding, accepted)
+			}
+		})
+	}
+}
+
+func TestToUTF8(t *testing.T) {
+	latin1, _ := charmap.ISO8859_1.NewEncoder().Bytes([]byte(encodingTestPage))
+	windows1252Meta, _ := charmap.Windows1252.NewEncoder().Bytes([]byte(`<html><head><meta charset="windows-1252"></head><body>Café</body></html>`))
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		expected    string
+	}{
+		{"utf-8 header", []byte("Café"), "text/html; charset=utf-8", "Café"},
+		{"latin-1 header", latin1, "text/html; charset=ISO-8859-1", encodingTestPage},
+		{"meta tag", windows1252Meta, "text/html", `<html><head><meta charset="windows-1252"></head><body>Café</body></html>`},
+		{"undeclared utf-8", []byte("Café"), "text/html", "Café"},
+		{"byte order mark", append([]byte{0xEF, 0xBB, 0xBF}, []byte("Café")...), "text/html", "Café"},
+	}
+
+	for _, test := range tests {
+		result, err := toUTF8(test.body, test.contentType)
+		if err != nil {
+			t.Errorf("For %s, unexpected error: %v", test.name, err)
+			continue
+		}
+		if string(result) != test.expected {
+			t.Errorf("For %s, expected %q, but got %q", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestContentEncodingEmptyBody(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate", "gzip, deflate"} {
+		t.Run(encoding, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Encoding", encoding)
+				w.WriteHeader(http.StatusTooManyRequests)
+			}))
+			defer server.Close()
+
+			// The status of the empty response is kept, instead of failing to
+			// decode it.
+			_, err := GetCompanyFromUrl(NewScrapeClient(WithRetries(1)), server.URL+"/company/cafe", false)
+			if !errors.Is(err, ErrRateLimited) {
+				t.Errorf("Expected ErrRateLimited, but got %v", err)
+			}
+		})
+	}
+}
+
+func TestDoDebugDecodes(t *testing.T) {
+	var accepted string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepted = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compress(t, "gzip", []byte(encodingTestPage)))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	if err != nil {
+		t.Fatalf("Error creating request: %v", err)
+	}
+	resp, err := NewScrapeClient().DoDebug(req)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Error reading body: %v", err)
+	}
+	if string(body) != encodingTestPage {
+		t.Errorf("Expected the decoded page, but got %q", body)
+	}
+	if accepted != acceptEncoding {
+		t.Errorf("Expected Accept-Encoding %q, but got %q", acceptEncoding, accepted)
+	}
+}
@@ -1,13 +1,128 @@
 package linkedin
 
-import "net/http"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// statusLinkedInBlocked is the non-standard status code LinkedIn answers with
+// when it refuses to serve a client.
+const statusLinkedInBlocked = 999
+
+// Sentinel errors classifying why a fetch failed. Use errors.Is to test for
+// them, and errors.As with *HTTPError or *ParseError for the details.
+var (
+	ErrRateLimited = errors.New("rate limited")
+	ErrAuthWall    = errors.New("auth wall")
+	ErrNotFound    = errors.New("not found")
+	ErrEmptyParse  = errors.New("empty parse")
+	ErrCaptcha     = errors.New("captcha")
+)
 
 // HTTPError represents an HTTP error with a status code.
 type HTTPError struct {
 	StatusCode int
 	Message    string
+	URL        string
+	RetryAfter time.Duration
+	// Kind is one of the sentinel errors, or nil when the error is not
+	// classified.
+	Kind error
 }
 
 func (e *HTTPError) Error() string {
-	return http.StatusText(e.StatusCode)
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.URL != "" {
+		msg = fmt.Sprintf("%s: %s", e.URL, msg)
+	}
+	if e.RetryAfter > 0 {
+		msg = fmt.Sprintf("%s (retry after %v)", msg, e.RetryAfter)
+	}
+	return msg
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Kind
+}
+
+// ParseError is returned when a page was fetched, but none of the fields of
+// the expected entity could be found in it.
+type ParseError struct {
+	URL    string
+	Entity string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: no %s found in page", e.URL, e.Entity)
+}
+
+func (e *ParseError) Unwrap() error {
+	return ErrEmptyParse
+}
+
+// newHTTPError classifies a response with a non-2xx status code.
+func newHTTPError(resp *http.Response) *HTTPError {
+	httpErr := &HTTPError{
+		StatusCode: resp.StatusCode,
+		Message:    fmt.Sprintf("received non-2xx response: %d %s", resp.StatusCode, resp.Status),
+		URL:        resp.Request.URL.String(),
+	}
+	if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		httpErr.RetryAfter = wait
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, statusLinkedInBlocked:
+		httpErr.Kind = ErrRateLimited
+	case http.StatusNotFound, http.StatusGone:
+		httpErr.Kind = ErrNotFound
+	}
+	return httpErr
+}
+
+// authWallPaths are the paths LinkedIn redirects anonymous clients to instead
+// of serving the requested page.
+var authWallPaths = []string{"/authwall", "/login", "/uas/login", "/signup"}
+
+// authWallTitles are the titles of the LinkedIn sign in and sign up pages.
+var authWallTitles = []string{"Sign Up | LinkedIn", "Sign In | LinkedIn", "LinkedIn Login, Sign in | LinkedIn"}
+
+// detectBlockPage returns an error when a 2xx response is actually an auth
+// wall or a captcha challenge rather than the requested page.
+func detectBlockPage(resp *http.Response, doc *goquery.Document) error {
+	finalURL := resp.Request.URL
+	newErr := func(kind error) error {
+		return &HTTPError{
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("served %s page %s", kind, finalURL.String()),
+			URL:        finalURL.String(),
+			Kind:       kind,
+		}
+	}
+
+	if strings.Contains(finalURL.Path, "/checkpoint/challenge") || strings.HasPrefix(finalURL.Path, "/sorry/") ||
+		doc.Find("#captcha-form, .g-recaptcha, #recaptcha").Length() > 0 {
+		return newErr(ErrCaptcha)
+	}
+
+	for _, path := range authWallPaths {
+		if strings.HasPrefix(finalURL.Path, path) {
+			return newErr(ErrAuthWall)
+		}
+	}
+	title := strings.TrimSpace(doc.Find("title").First().Text())
+	for _, wallTitle := range authWallTitles {
+		if strings.EqualFold(title, wallTitle) {
+			return newErr(ErrAuthWall)
+		}
+	}
+	return nil
 }
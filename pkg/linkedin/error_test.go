@@ -0,0 +1,99 @@
+package linkedin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPErrorError(t *testing.T) {
+	err := &HTTPError{StatusCode: 429, Message: "slow down", URL: "https://www.linkedin.com/company/tetrate", RetryAfter: time.Minute}
+	expected := "https://www.linkedin.com/company/tetrate: slow down (retry after 1m0s)"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+
+	err = &HTTPError{StatusCode: 404}
+	if err.Error() != "Not Found" {
+		t.Errorf("expected %q, got %q", "Not Found", err.Error())
+	}
+}
+
+func TestFetchErrorClassification(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ratelimited", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	mux.HandleFunc("/blocked", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statusLinkedInBlocked)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/company/redirected", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/authwall?trk=public_profile", http.StatusFound)
+	})
+	mux.HandleFunc("/authwall", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><head><title>Sign Up | LinkedIn</title></head></html>"))
+	})
+	mux.HandleFunc("/company/signup", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><head><title>Sign Up | LinkedIn</title></head></html>"))
+	})
+	mux.HandleFunc("/company/captcha", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><form id="captcha-form"></form></body></html>`))
+	})
+	mux.HandleFunc("/company/empty", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body></body></html>"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		path     string
+		expected error
+		status   int
+	}{
+		{"/ratelimited", ErrRateLimited, http.StatusTooManyRequests},
+		{"/blocked", ErrRateLimited, statusLinkedInBlocked},
+		{"/missing", ErrNotFound, http.StatusNotFound},
+		{"/company/redirected", ErrAuthWall, http.StatusOK},
+		{"/company/signup", ErrAuthWall, http.StatusOK},
+		{"/company/captcha", ErrCaptcha, http.StatusOK},
+		{"/company/empty", ErrEmptyParse, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			// A client per path, as every block signal slows the host down.
+			client := NewScrapeClient(WithRetries(1))
+			_, err := GetCompanyFromUrl(client, server.URL+tt.path, false)
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("Expected %v for %s, but got %v", tt.expected, tt.path, err)
+			}
+			var httpErr *HTTPError
+			if tt.status == 0 {
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) || parseErr.URL != server.URL+tt.path {
+					t.Errorf("Expected a ParseError for %s, but got %v", tt.path, err)
+				}
+				return
+			}
+			if !errors.As(err, &httpErr) {
+				t.Fatalf("Expected an HTTPError for %s, but got %T", tt.path, err)
+			}
+			if httpErr.StatusCode != tt.status {
+				t.Errorf("Expected status %d for %s, but got %d", tt.status, tt.path, httpErr.StatusCode)
+			}
+			if httpErr.URL == "" {
+				t.Errorf("Expected the URL to be set for %s", tt.path)
+			}
+		})
+	}
+
+	_, err := GetCompanyFromUrl(NewScrapeClient(WithRetries(1)), server.URL+"/ratelimited", false)
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.RetryAfter != 2*time.Minute {
+		t.Errorf("Expected Retry-After of 2m, but got %v", httpErr.RetryAfter)
+	}
+}
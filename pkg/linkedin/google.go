@@ -2,51 +2,109 @@ package linkedin
 
 import (
 	"context"
+	"net/url"
+	"strconv"
 	"strings"
-	"time"
 
-	"github.com/corpix/uarand"
-	googlesearch "github.com/rocketlaunchr/google-search"
+	"github.com/PuerkitoBio/goquery"
 )
 
-const (
-	googleLinkedInCompanyPrefix = "site:linkedin.com/company"
-	googleLinkedInPostPrefix    = "site:linkedin.com/posts"
-	googleLinkedInPulsePrefix   = "site:linkedin.com/pulse"
-	googleLinkedInUserPrefix    = "site:linkedin.com/in"
-)
+const googleHost = "www.google.com"
 
-func GoogleGetLinkedInCompanyURLs(keywords []string, interval time.Duration, debug bool) ([]string, error) {
-	return googleSearch(googleLinkedInCompanyPrefix, keywords, interval, debug)
+// googleRecency maps recencies to the qdr values of the tbs parameter.
+var googleRecency = map[Recency]string{
+	RecencyDay:   "d",
+	RecencyWeek:  "w",
+	RecencyMonth: "m",
+	RecencyYear:  "y",
 }
 
-func GoogleGetLinkedInPostURLs(keywords []string, interval time.Duration, debug bool) ([]string, error) {
-	return googleSearch(googleLinkedInPostPrefix, keywords, interval, debug)
+func GoogleGetLinkedInCompanyURLs(client *ScrapeClient, keywords []string, debug bool) ([]string, error) {
+	return googleSearch(context.Background(), client, linkedInCompanySite, keywords, debug)
 }
 
-func GoogleGetLinkedInPulseURLs(keywords []string, interval time.Duration, debug bool) ([]string, error) {
-	return googleSearch(googleLinkedInPulsePrefix, keywords, interval, debug)
+func GoogleGetLinkedInCompanyURLsContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool) ([]string, error) {
+	return googleSearch(ctx, client, linkedInCompanySite, keywords, debug)
 }
 
-func GoogleGetLinkedInUserURLs(keywords []string, interval time.Duration, debug bool) ([]string, error) {
-	return googleSearch(googleLinkedInUserPrefix, keywords, interval, debug)
+func GoogleGetLinkedInPostURLs(client *ScrapeClient, keywords []string, debug bool) ([]string, error) {
+	return googleSearch(context.Background(), client, linkedInPostSite, keywords, debug)
 }
 
-func googleSearch(prefix string, keywords []string, interval time.Duration, debug bool) ([]string, error) {
-	query := prefix + " " + strings.Join(keywords, " ")
-	opts := googlesearch.SearchOptions{
-		Limit:          100,
-		UserAgent:      uarand.GetRandom(),
-		FollowNextPage: true,
-	}
-	results, err := googlesearch.Search(context.Background(), query, opts)
+func GoogleGetLinkedInPostURLsContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool) ([]string, error) {
+	return googleSearch(ctx, client, linkedInPostSite, keywords, debug)
+}
+
+func GoogleGetLinkedInPulseURLs(client *ScrapeClient, keywords []string, debug bool) ([]string, error) {
+	return googleSearch(context.Background(), client, linkedInPulseSite, keywords, debug)
+}
+
+func GoogleGetLinkedInPulseURLsContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool) ([]string, error) {
+	return googleSearch(ctx, client, linkedInPulseSite, keywords, debug)
+}
+
+func GoogleGetLinkedInUserURLs(client *ScrapeClient, keywords []string, debug bool) ([]string, error) {
+	return googleSearch(context.Background(), client, linkedInUserSite, keywords, debug)
+}
+
+func GoogleGetLinkedInUserURLsContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool) ([]string, error) {
+	return googleSearch(ctx, client, linkedInUserSite, keywords, debug)
+}
+
+// googleSearch searches Google only, regardless of the search engines of the
+// client. Keywords are parsed with ParseKeywords.
+func googleSearch(ctx context.Context, client *ScrapeClient, site string, keywords []string, debug bool) ([]string, error) {
+	query, err := ParseKeywords(keywords)
 	if err != nil {
 		return nil, err
 	}
+	return searchWith(ctx, client, []SearchEngine{GoogleEngine{}}, query.withSite(site), debug)
+}
+
+// GoogleEngine searches Google.
+type GoogleEngine struct{}
+
+func (GoogleEngine) Name() string {
+	return "google"
+}
+
+// Search scrapes the Google result pages of query, following the next page
+// link until searchMaxResults is reached.
+func (GoogleEngine) Search(ctx context.Context, client *ScrapeClient, query *Query) (SearchHits, error) {
+	return searchPages(ctx, client, googleSearchURL(query), parseGoogleResults)
+}
+
+func googleSearchURL(query *Query) string {
+	params := url.Values{}
+	params.Set("q", query.SearchTerms())
+	params.Set("hl", "en")
+	params.Set("num", strconv.Itoa(searchMaxResults))
+	if recency, ok := googleRecency[query.Posted()]; ok {
+		params.Set("tbs", "qdr:"+recency)
+	}
+	return "https://" + googleHost + "/search?" + params.Encode()
+}
+
+// parseGoogleResults returns the hits of a Google result page and the
+// absolute URL of the next page, if any.
+func parseGoogleResults(doc *goquery.Document, pageURL *url.URL) (SearchHits, string) {
+	var hits SearchHits
+	doc.Find("div.g").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Find("a").First().Attr("href")
+		href = strings.TrimSpace(href)
+		if href == "" || href == "#" || s.Find("h3").Length() == 0 {
+			return
+		}
+		hits = append(hits, &SearchHit{
+			URL:     href,
+			Title:   cleanText(s.Find("h3").First()),
+			Snippet: cleanText(s.Find("div.VwiC3b").First()),
+		})
+	})
 
-	var urls []string
-	for _, result := range results {
-		urls = append(urls, result.URL)
+	next, exists := doc.Find("a#pnnext").Attr("href")
+	if !exists {
+		return hits, ""
 	}
-	return urls, nil
+	return hits, resolveURL(pageURL, next)
 }
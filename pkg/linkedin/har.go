@@ -0,0 +1,337 @@
+package linkedin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrNotRecorded is returned in replay mode when a request is not in the HAR
+// file.
+var ErrNotRecorded = errors.New("request not recorded")
+
+// HAR is an HTTP Archive, as written by browsers' developer tools. Only the
+// fields needed to replay a session are kept.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+type HARRequest struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []HARHeader `json:"headers"`
+	QueryString []HARHeader `json:"queryString"`
+	Cookies     []HARHeader `json:"cookies"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []HARHeader `json:"headers"`
+	Cookies     []HARHeader `json:"cookies"`
+	Content     HARContent  `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type HARHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// LoadHAR reads a HAR file.
+func LoadHAR(path string) (*HAR, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open HAR file: %w", err)
+	}
+	var har HAR
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("failed to read HAR file: %w", err)
+	}
+	return &har, nil
+}
+
+// harFlushEntries is the number of entries a HARRecorder records before it
+// writes its file again.
+const harFlushEntries = 50
+
+// HARRecorder is an http.RoundTripper that records every request and response
+// passing through it. The HAR file is written on Close, and along the way
+// every harFlushEntries entries, so a run that is killed still leaves most of
+// its entries behind.
+type HARRecorder struct {
+	path string
+	next http.RoundTripper
+
+	mu        sync.Mutex
+	har       HAR
+	unwritten int
+}
+
+// NewHARRecorder creates a HARRecorder writing to path.
+func NewHARRecorder(path string) *HARRecorder {
+	return &HARRecorder{
+		path: path,
+		har: HAR{Log: HARLog{
+			Version: "1.2",
+			Creator: HARCreator{Name: "lictl", Version: "1.0"},
+			Entries: []HAREntry{},
+		}},
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *HARRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := r.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// Bodies are stored decoded, as browsers do, so recordings stay readable.
+	if err := decompressBody(resp); err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	elapsed := float64(time.Since(start).Milliseconds())
+
+	entry := HAREntry{
+		StartedDateTime: start,
+		Time:            elapsed,
+		Request: HARRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Headers:     harHeaders(req.Header),
+			QueryString: harQueryString(req),
+			Cookies:     []HARHeader{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Response: HARResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Headers:     harHeaders(resp.Header),
+			Cookies:     []HARHeader{},
+			Content:     harContent(resp.Header.Get("Content-Type"), body),
+			RedirectURL: resp.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(body),
+		},
+		Timings: HARTimings{Wait: elapsed},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.har.Log.Entries = append(r.har.Log.Entries, entry)
+	if r.unwritten++; r.unwritten >= harFlushEntries {
+		if err := r.save(); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// Close writes the entries recorded so far to the HAR file.
+func (r *HARRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.save()
+}
+
+// Entries returns the entries recorded so far.
+func (r *HARRecorder) Entries() []HAREntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]HAREntry{}, r.har.Log.Entries...)
+}
+
+func (r *HARRecorder) save() error {
+	data, err := json.MarshalIndent(r.har, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(r.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write HAR file: %w", err)
+	}
+	r.unwritten = 0
+	return nil
+}
+
+// HARReplayer is an http.RoundTripper that answers requests from a HAR file
+// without touching the network. Requests are matched on method and
+// normalized URL. A URL recorded several times is answered with its
+// responses in recorded order, repeating the last one.
+type HARReplayer struct {
+	mu      sync.Mutex
+	entries map[string][]HAREntry
+	served  map[string]int
+}
+
+// NewHARReplayer creates a HARReplayer serving the entries of har.
+func NewHARReplayer(har *HAR) (*HARReplayer, error) {
+	r := &HARReplayer{
+		entries: make(map[string][]HAREntry),
+		served:  make(map[string]int),
+	}
+	for _, entry := range har.Log.Entries {
+		req, err := http.NewRequest(entry.Request.Method, entry.Request.URL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HAR entry %s: %w", entry.Request.URL, err)
+		}
+		key := harKey(req)
+		r.entries[key] = append(r.entries[key], entry)
+	}
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *HARReplayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	key := harKey(req)
+	r.mu.Lock()
+	entries := r.entries[key]
+	if len(entries) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, req.URL.String())
+	}
+	i := r.served[key]
+	if i >= len(entries) {
+		i = len(entries) - 1
+	}
+	r.served[key] = i + 1
+	r.mu.Unlock()
+
+	entry := entries[i].Response
+	body := []byte(entry.Content.Text)
+	if entry.Content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(entry.Content.Text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HAR content for %s: %w", req.URL.String(), err)
+		}
+		body = decoded
+	}
+
+	header := make(http.Header)
+	for _, h := range entry.Headers {
+		header.Add(h.Name, h.Value)
+	}
+	header.Del("Content-Length")
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.Status, entry.StatusText),
+		StatusCode:    entry.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func harKey(req *http.Request) string {
+	return req.Method + " " + normalizeURL(req.URL)
+}
+
+// redactedHeaders carry session secrets, so their values are not recorded.
+var redactedHeaders = map[string]bool{"Authorization": true, "Cookie": true, "Set-Cookie": true}
+
+// harHeaders flattens header sorted by name, so recordings of the same
+// session compare equal.
+func harHeaders(header http.Header) []HARHeader {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := []HARHeader{}
+	for _, name := range names {
+		for _, value := range header[name] {
+			if redactedHeaders[http.CanonicalHeaderKey(name)] {
+				value = "REDACTED"
+			}
+			headers = append(headers, HARHeader{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+func harQueryString(req *http.Request) []HARHeader {
+	return harHeaders(http.Header(req.URL.Query()))
+}
+
+// harContent stores text bodies as is and binary bodies base64 encoded.
+func harContent(mimeType string, body []byte) HARContent {
+	content := HARContent{Size: len(body), MimeType: mimeType}
+	if utf8.Valid(body) {
+		content.Text = string(body)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(body)
+		content.Encoding = "base64"
+	}
+	return content
+}
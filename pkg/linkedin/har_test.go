@@ -0,0 +1,76 @@
+package linkedin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestHARRecordReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><body><h1 class="top-card-layout__title">Tetrate</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "session.har")
+	client := NewScrapeClient(WithHARRecorder(NewHARRecorder(path)))
+	if _, err := GetCompanyFromUrl(client, server.URL+"/company/tetrate", false); err != nil {
+		t.Fatalf("Error recording session: %v", err)
+	}
+	server.Close()
+	if err := client.Close(); err != nil {
+		t.Fatalf("Error closing client: %v", err)
+	}
+
+	har, err := LoadHAR(path)
+	if err != nil {
+		t.Fatalf("Error loading HAR file: %v", err)
+	}
+	if len(har.Log.Entries) != 1 {
+		t.Fatalf("Expected 1 recorded entry, but got %d", len(har.Log.Entries))
+	}
+	if headers := har.Log.Entries[0].Request.Headers; len(headers) == 0 {
+		t.Errorf("Expected recorded request headers, but got none")
+	}
+
+	replayer, err := NewHARReplayer(har)
+	if err != nil {
+		t.Fatalf("Error creating replayer: %v", err)
+	}
+	client = NewScrapeClient(WithHARReplayer(replayer))
+	company, err := GetCompanyFromUrl(client, server.URL+"/company/tetrate?trk=replay", false)
+	if err != nil {
+		t.Fatalf("Error replaying session: %v", err)
+	}
+	if company.Name != "Tetrate" {
+		t.Errorf("Expected company name Tetrate, but got %s", company.Name)
+	}
+
+	_, err = GetCompanyFromUrl(client, server.URL+"/company/other", false)
+	if !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("Expected ErrNotRecorded, but got %v", err)
+	}
+}
+
+func TestHARContent(t *testing.T) {
+	tests := []struct {
+		body     []byte
+		encoding string
+	}{
+		{[]byte("<html></html>"), ""},
+		{[]byte{0x1f, 0x8b, 0xff, 0x00}, "base64"},
+	}
+
+	for _, test := range tests {
+		content := harContent("text/html", test.body)
+		if content.Encoding != test.encoding {
+			t.Errorf("For body %v, expected encoding %q, but got %q", test.body, test.encoding, content.Encoding)
+		}
+		if content.Size != len(test.body) {
+			t.Errorf("For body %v, expected size %d, but got %d", test.body, len(test.body), content.Size)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package linkedin
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// SearchHit represents a single search engine result, as found without
+// fetching the page it points to.
+type SearchHit struct {
+	URL     string `json:"url"     csv:"url"`
+	Title   string `json:"title"   csv:"title"`
+	Snippet string `json:"snippet" csv:"snippet"`
+	Rank    int    `json:"rank"    csv:"rank"`
+	Engine  string `json:"engine"  csv:"engine"`
+	Query   string `json:"query"   csv:"query"`
+}
+
+func (h *SearchHit) CsvContent() string {
+	if h == nil {
+		return ""
+	}
+	return CsvContent(h)
+}
+
+func (h *SearchHit) CsvHeader() string {
+	if h == nil {
+		return ""
+	}
+	return CsvHeader(h)
+}
+
+func (h *SearchHit) Json() string {
+	if h == nil {
+		return ""
+	}
+	return Json(h)
+}
+
+// UnmarshalJSON also accepts a bare URL, which is how search results were
+// cached before hits were kept.
+func (h *SearchHit) UnmarshalJSON(data []byte) error {
+	var url string
+	if err := json.Unmarshal(data, &url); err == nil {
+		*h = SearchHit{URL: url}
+		return nil
+	}
+	type plain SearchHit
+	return json.Unmarshal(data, (*plain)(h))
+}
+
+type SearchHits []*SearchHit
+
+func (hs SearchHits) Len() int {
+	return len(hs)
+}
+
+func (hs SearchHits) Get(i int) Serializable {
+	return Serializable(hs[i])
+}
+
+// URLs returns the URLs of the hits.
+func (hs SearchHits) URLs() []string {
+	urls := make([]string, len(hs))
+	for i, hit := range hs {
+		urls[i] = hit.URL
+	}
+	return urls
+}
+
+func SearchCompanyHitsOnline(client *ScrapeClient, keywords []string, debug bool) (SearchHits, error) {
+	return SearchCompanyHitsOnlineContext(context.Background(), client, keywords, debug)
+}
+
+// SearchCompanyHitsOnlineContext returns the search engine hits for LinkedIn
+// companies matching keywords, without fetching the company pages.
+func SearchCompanyHitsOnlineContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool) (SearchHits, error) {
+	return searchLinkedInHits(ctx, client, linkedInCompanySite, keywords, debug)
+}
+
+func SearchPostHitsOnline(client *ScrapeClient, keywords []string, debug bool) (SearchHits, error) {
+	return SearchPostHitsOnlineContext(context.Background(), client, keywords, debug)
+}
+
+// SearchPostHitsOnlineContext returns the search engine hits for LinkedIn
+// posts matching keywords, without fetching the posts.
+func SearchPostHitsOnlineContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool) (SearchHits, error) {
+	return searchLinkedInHits(ctx, client, linkedInPostSite, keywords, debug)
+}
+
+func SearchPulseHitsOnline(client *ScrapeClient, keywords []string, debug bool) (SearchHits, error) {
+	return SearchPulseHitsOnlineContext(context.Background(), client, keywords, debug)
+}
+
+// SearchPulseHitsOnlineContext returns the search engine hits for LinkedIn
+// pulse articles matching keywords, without fetching the articles.
+func SearchPulseHitsOnlineContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool) (SearchHits, error) {
+	return searchLinkedInHits(ctx, client, linkedInPulseSite, keywords, debug)
+}
+
+func SearchUserHitsOnline(client *ScrapeClient, keywords []string, debug bool) (SearchHits, error) {
+	return SearchUserHitsOnlineContext(context.Background(), client, keywords, debug)
+}
+
+// SearchUserHitsOnlineContext returns the search engine hits for LinkedIn
+// users matching keywords, without fetching the profiles.
+func SearchUserHitsOnlineContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool) (SearchHits, error) {
+	return searchLinkedInHits(ctx, client, linkedInUserSite, keywords, debug)
+}
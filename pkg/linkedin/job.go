@@ -1,17 +1,33 @@
 package linkedin
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"net/url"
+	"slices"
 	"strings"
-	"time"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
-const baseURL = "https://www.linkedin.com/jobs-guest/jobs/api/seeMoreJobPostings/search?"
+const (
+	baseURL = "https://www.linkedin.com/jobs-guest/jobs/api/seeMoreJobPostings/search?"
+	// searchPageURL serves the first result page along with the number of
+	// results, which the pages of baseURL leave out.
+	searchPageURL = "https://www.linkedin.com/jobs/search?"
+
+	jobsPageSize  = 25
+	maxJobsOffset = 975
+)
+
+// jobsRecency maps recencies to the values of the f_TPR parameter. LinkedIn
+// has no filter for the last year, so that recency finds all jobs.
+var jobsRecency = map[Recency]string{
+	RecencyDay:   "r86400",
+	RecencyWeek:  "r604800",
+	RecencyMonth: "r2592000",
+}
 
 // Job represents the structure of a LinkedIn job.
 type Job struct {
@@ -22,6 +38,7 @@ type Job struct {
 	JobTitle           string `json:"jobTitle"           csv:"jobTitle"`
 	JobURN             string `json:"jobURN"             csv:"jobURN"`
 	Location           string `json:"location"           csv:"location"`
+	Query              string `json:"query"              csv:"query"`
 }
 
 func (j *Job) CsvContent() string {
@@ -55,64 +72,267 @@ func (js Jobs) Get(i int) Serializable {
 	return Serializable(js[i])
 }
 
-func SearchJobsOnline(regions []string, keywords []string, interval time.Duration, debug bool) (Jobs, error) {
-	var allJobs []*Job
+func SearchJobsOnline(client *ScrapeClient, regions []string, keywords []string, debug bool, opts ...JobSearchOption) (Jobs, error) {
+	return SearchJobsOnlineContext(context.Background(), client, regions, keywords, debug, opts...)
+}
+
+// SearchJobsOnlineContext is like SearchJobsOnline, but stops paging when ctx
+// is done. When the search stops with an error, the jobs fetched so far are
+// returned along with it.
+func SearchJobsOnlineContext(ctx context.Context, client *ScrapeClient, regions []string, keywords []string, debug bool, opts ...JobSearchOption) (Jobs, error) {
+	return collect(func(yield func(*Job, error) bool) error {
+		return StreamJobsOnlineContext(ctx, client, regions, keywords, debug, yield, opts...)
+	})
+}
+
+func StreamJobsOnline(client *ScrapeClient, regions []string, keywords []string, debug bool, yield func(*Job, error) bool, opts ...JobSearchOption) error {
+	return StreamJobsOnlineContext(context.Background(), client, regions, keywords, debug, yield, opts...)
+}
+
+// StreamJobsOnlineContext is like SearchJobsOnlineContext, but passes every
+// job to yield as soon as its result page is parsed, instead of returning
+// them all at the end. A failing page stops the search with an error, so
+// yield only ever gets a nil error. Returning false from yield stops the
+// search.
+//
+// Every combination of regions and keywords is searched on its own, as a
+// JobQuery, and keywords can use the query language. Jobs are tagged with the
+// first query that found them, and jobs found again by a later query or page
+// are skipped. Result pages completed in the checkpoint of client are
+// skipped, and every fetched page is recorded there along with its new jobs.
+// Options such as WithJobFilters narrow the search down further.
+func StreamJobsOnlineContext(ctx context.Context, client *ScrapeClient, regions []string, keywords []string, debug bool, yield func(*Job, error) bool, opts ...JobSearchOption) error {
+	queries, err := jobQueries(regions, keywords)
+	if err != nil {
+		return err
+	}
+	search := newJobSearch(opts)
+	if err := search.filters.Validate(); err != nil {
+		return err
+	}
+
+	// Jobs resumed from the checkpoint were written already.
+	seen := make(map[string]bool)
+	if client.checkpoint != nil {
+		resumed, err := CheckpointResults[*Job](client.checkpoint)
+		if err != nil {
+			return err
+		}
+		for _, job := range resumed {
+			seen[job.JobURN] = true
+		}
+		search.results = len(resumed)
+	}
+
+	for i, query := range queries {
+		if search.full() {
+			return nil
+		}
+		count := JobQueryCount{Query: query}
+		laterPages := (len(queries) - i - 1) * search.maxQueryPages()
+		stopped, err := search.streamQuery(ctx, client, query, seen, &count, laterPages, debug, yield)
+		if search.queryCounts != nil {
+			search.queryCounts(count)
+		}
+		if err != nil || stopped {
+			return err
+		}
+	}
+	return nil
+}
 
-	for offset := 0; offset <= 975; offset += 25 {
-		params := url.Values{}
-		params.Add("location", strings.Join(regions, ","))
-		params.Add("keywords", strings.Join(keywords, ","))
-		params.Add("start", fmt.Sprintf("%d", offset))
-		url := baseURL + params.Encode()
+// streamQuery pages through the results of query, passing the jobs not in
+// seen to yield. It reports whether yield or the maximum number of results
+// stopped the search. laterPages is the number of pages of the queries after
+// query, which are skipped when the search stops.
+//
+// The number of results on the first page limits the pages fetched, and
+// paging stops at an empty page or at a page repeating the previous one,
+// which LinkedIn serves past the last page.
+func (s *jobSearch) streamQuery(ctx context.Context, client *ScrapeClient, query JobQuery, seen map[string]bool, count *JobQueryCount, laterPages int, debug bool, yield func(*Job, error) bool) (bool, error) {
+	pages := s.maxQueryPages()
+	var previous []string
+	for page := 0; page < pages; page++ {
+		url := jobSearchURL(query.Region, query.query, s.filters, page*jobsPageSize)
+		if client.checkpoint != nil {
+			if entry, done := client.checkpoint.completedPage(url); done {
+				// The counts and total of a resumed page are those of the run
+				// that fetched it, so the query reports and plans the same.
+				count.Found += entry.found()
+				count.New += len(entry.Results)
+				if page == 0 && entry.Total > 0 {
+					pages = plannedPages(count, entry.Total, pages)
+				}
+				if entry.found() == 0 {
+					break
+				}
+				previous = entry.IDs
+				continue
+			}
+		}
 		if debug {
-			fmt.Printf("going to fetch search url %v", url)
+			fmt.Printf("going to fetch page %d of %d of search url %v", page+1, pages, url)
 		}
 
-		jobs, err := GetJobsFromSearchUrl(url, debug)
+		result, err := getJobSearchPage(ctx, client, url, debug)
+		if page == 0 && isBlockSignal(err) {
+			// The search page is the one most prone to the auth wall, so the
+			// guest endpoint stands in for it, without the number of results.
+			guestURL := guestJobSearchURL(query.Region, query.query, s.filters, 0)
+			if debug {
+				fmt.Printf("search page blocked, falling back to %v", guestURL)
+			}
+			result, err = getJobSearchPage(ctx, client, guestURL, debug)
+		}
 		if err != nil {
-			if httpErr, ok := err.(*HTTPError); ok && httpErr.StatusCode == http.StatusTooManyRequests {
-				return allJobs, err // Return the jobs fetched so far along with the error
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			if stopErr := skipRemaining(err, pages-page+laterPages); stopErr != nil {
+				return false, stopErr
 			}
-			return nil, err
+			return false, err
 		}
-		if len(jobs) == 0 {
+		if page == 0 && result.total > 0 {
+			pages = plannedPages(count, result.total, pages)
+		}
+
+		urns := make([]string, len(result.jobs))
+		for i, job := range result.jobs {
+			urns[i] = job.JobURN
+		}
+		repeated := len(urns) > 0 && slices.Equal(urns, previous)
+		previous = urns
+
+		var fresh []*Job
+		if !repeated {
+			for _, job := range result.jobs {
+				if job.JobURN != "" && seen[job.JobURN] {
+					continue
+				}
+				seen[job.JobURN] = true
+				job.Query = query.String()
+				fresh = append(fresh, job)
+			}
+			count.Found += len(result.jobs)
+			count.New += len(fresh)
+		}
+
+		if client.checkpoint != nil {
+			results := make([]any, len(fresh))
+			for i, job := range fresh {
+				results[i] = job
+			}
+			// A repeated page is recorded as empty, so a resumed run stops
+			// there as well. The URNs let a resumed run tell whether the next
+			// page repeats this one.
+			entry := checkpointEntry{Key: url, Found: len(result.jobs), Total: count.Total, IDs: urns}
+			if repeated {
+				entry.Found, entry.IDs = 0, nil
+			}
+			// A checkpoint that fails to save must not fail the run.
+			if err := client.checkpoint.completePage(entry, results...); err != nil {
+				log.Printf("failed to save checkpoint %s: %v", client.checkpoint.Path(), err)
+			}
+		}
+		if len(result.jobs) == 0 || repeated {
 			break
 		}
-		allJobs = append(allJobs, jobs...)
-		time.Sleep(interval)
+		for _, job := range fresh {
+			if !yield(job, nil) {
+				return true, nil
+			}
+			s.results++
+			if s.full() {
+				return true, nil
+			}
+		}
 	}
-	return allJobs, nil
+	return false, nil
 }
 
-func GetJobsFromSearchUrl(url string, debug bool) (Jobs, error) {
-	resp, err := http.Get(url)
+// plannedPages records total as the number of results of the query in count,
+// and returns the number of pages holding them, or pages if that is less.
+func plannedPages(count *JobQueryCount, total, pages int) int {
+	count.Total = total
+	if planned := (total + jobsPageSize - 1) / jobsPageSize; planned < pages {
+		return planned
+	}
+	return pages
+}
+
+// jobSearchURL returns the URL of the result page of the job search starting
+// at offset. The first page is the search page, as it holds the number of
+// results.
+func jobSearchURL(region string, query *Query, filters JobFilters, offset int) string {
+	if offset == 0 {
+		return searchPageURL + jobSearchParams(region, query, filters, offset).Encode()
+	}
+	return guestJobSearchURL(region, query, filters, offset)
+}
+
+// guestJobSearchURL returns the URL of the result page of the job search
+// starting at offset on the guest endpoint.
+func guestJobSearchURL(region string, query *Query, filters JobFilters, offset int) string {
+	return baseURL + jobSearchParams(region, query, filters, offset).Encode()
+}
+
+func jobSearchParams(region string, query *Query, filters JobFilters, offset int) url.Values {
+	params := url.Values{}
+	params.Add("location", region)
+	params.Add("keywords", query.Keywords())
+	filters.addParams(params, query.Posted())
+	params.Add("start", fmt.Sprintf("%d", offset))
+	return params
+}
+
+func GetJobsFromSearchUrl(client *ScrapeClient, url string, debug bool) (Jobs, error) {
+	return GetJobsFromSearchUrlContext(context.Background(), client, url, debug)
+}
+
+// GetJobsFromSearchUrlContext is like GetJobsFromSearchUrl, but aborts the
+// request when ctx is done.
+func GetJobsFromSearchUrlContext(ctx context.Context, client *ScrapeClient, url string, debug bool) (Jobs, error) {
+	result, err := getJobSearchPage(ctx, client, url, debug)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch LinkedIn jobs: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return result.jobs, nil
+}
 
-	// Check for non-2xx status codes
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, &HTTPError{
-			StatusCode: resp.StatusCode,
-			Message:    fmt.Sprintf("received non-2xx response: %d %s", resp.StatusCode, resp.Status),
-		}
+// jobSearchPage is a result page of the job search. total is the number of
+// results of the search, if the page tells.
+type jobSearchPage struct {
+	jobs  Jobs
+	total int
+}
+
+func getJobSearchPage(ctx context.Context, client *ScrapeClient, url string, debug bool) (*jobSearchPage, error) {
+	req, err := newRequest(ctx, url)
+	if err != nil {
+		return nil, err
 	}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	doc, err := client.fetchDocument(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		return nil, err
 	}
 
 	var jobs []*Job
 	doc.Find("li").Each(func(i int, s *goquery.Selection) {
 		var job Job
 
+		// The search page has list items other than jobs.
+		urn := strings.Split(s.Find("div").AttrOr("data-entity-urn", ""), ":")
+		if len(urn) < 4 {
+			return
+		}
+
 		companyName := strings.TrimSpace(s.Find("h4").Text())
 		datePosted := strings.TrimSpace(s.Find(".job-search-card__listdate").AttrOr("datetime", ""))
 		jobLink := cleanURL(s.Find(".base-card__full-link").AttrOr("href", ""))
 		jobTitle := strings.TrimSpace(s.Find(".base-search-card__title").Text())
-		jobURN := strings.Split(s.Find("div").AttrOr("data-entity-urn", ""), ":")[3]
+		jobURN := urn[3]
 		location := strings.TrimSpace(s.Find(".job-search-card__location").Text())
 
 		var companyLinkedInURL string
@@ -142,5 +362,6 @@ func GetJobsFromSearchUrl(url string, debug bool) (Jobs, error) {
 		}
 	}
 
-	return jobs, nil
+	total, _ := extractJobCount(doc.Find(".results-context-header__job-count").First().Text())
+	return &jobSearchPage{jobs: jobs, total: total}, nil
 }
@@ -0,0 +1,267 @@
+package linkedin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+const jobViewURL = "https://www.linkedin.com/jobs/view/"
+
+// jobIDPattern matches the job ID at the end of a /jobs/view/ URL, with or
+// without the title slug in front of it, and of a guest jobPosting URL.
+var jobIDPattern = regexp.MustCompile(`/(?:jobs/view/(?:[^/]*-)?|jobs-guest/jobs/api/jobPosting/)(\d+)/?$`)
+
+// JobDetail represents the structure of a LinkedIn job page.
+type JobDetail struct {
+	ApplicantCount     int          `json:"applicantCount"     csv:"applicantCount"`
+	ApplyURL           string       `json:"applyURL"           csv:"applyURL"`
+	CompanyLinkedInURL string       `json:"companyLinkedInURL" csv:"companyLinkedInURL"`
+	CompanyName        string       `json:"companyName"        csv:"companyName"`
+	DatePosted         string       `json:"datePosted"         csv:"datePosted"`
+	Description        string       `json:"description"        csv:"description"`
+	EasyApply          bool         `json:"easyApply"          csv:"easyApply"`
+	EmploymentType     string       `json:"employmentType"     csv:"employmentType"`
+	Industries         string       `json:"industries"         csv:"industries"`
+	JobFunction        string       `json:"jobFunction"        csv:"jobFunction"`
+	JobLink            string       `json:"jobLink"            csv:"jobLink"`
+	JobTitle           string       `json:"jobTitle"           csv:"jobTitle"`
+	JobURN             string       `json:"jobURN"             csv:"jobURN"`
+	Location           string       `json:"location"           csv:"location"`
+	Salary             string       `json:"salary"             csv:"salary"`
+	SeniorityLevel     string       `json:"seniorityLevel"     csv:"seniorityLevel"`
+	Sources            FieldSources `json:"sources,omitempty"  csv:"-"`
+}
+
+func (j *JobDetail) CsvContent() string {
+	if j == nil {
+		return ""
+	}
+	return CsvContent(j)
+}
+
+func (j *JobDetail) CsvHeader() string {
+	if j == nil {
+		return ""
+	}
+	return CsvHeader(j)
+}
+
+func (j *JobDetail) Json() string {
+	if j == nil {
+		return ""
+	}
+	return Json(j)
+}
+
+type JobDetails []*JobDetail
+
+func (jds JobDetails) Len() int {
+	return len(jds)
+}
+
+func (jds JobDetails) Get(i int) Serializable {
+	return Serializable(jds[i])
+}
+
+func GetJobDetailFromUrl(client *ScrapeClient, url string, debug bool) (*JobDetail, error) {
+	return GetJobDetailFromUrlContext(context.Background(), client, url, debug)
+}
+
+// GetJobDetailFromUrlContext is like GetJobDetailFromUrl, but aborts the
+// request when ctx is done. The url is either a job page under /jobs/view/ or
+// the guest jobPosting endpoint, which serves the same details without the
+// rest of the page.
+func GetJobDetailFromUrlContext(ctx context.Context, client *ScrapeClient, url string, debug bool) (*JobDetail, error) {
+	if debug {
+		fmt.Printf("going to fetch job from url %v", url)
+	}
+
+	req, err := newRequest(ctx, url)
+	if err != nil {
+		return &JobDetail{}, err
+	}
+
+	return getJobDetailFromRequest(client, req, debug)
+}
+
+func getJobDetailFromRequest(client *ScrapeClient, req *http.Request, debug bool) (*JobDetail, error) {
+	doc, err := client.fetchDocument(req)
+	if err != nil {
+		return &JobDetail{}, err
+	}
+
+	var jobDetail JobDetail
+	data := parseStructuredData(doc)
+	posting := data.object("JobPosting")
+	sources := make(FieldSources)
+
+	var cssCompanyLinkedInURL string
+	if href, exists := doc.Find(".topcard__org-name-link").Attr("href"); exists {
+		cssCompanyLinkedInURL = cleanURL(href)
+	}
+
+	criteria := make(map[string]string)
+	doc.Find(".description__job-criteria-item").Each(func(i int, s *goquery.Selection) {
+		criteria[cleanText(s.Find(".description__job-criteria-subheader"))] = cleanText(s.Find(".description__job-criteria-text"))
+	})
+
+	// The employment type and industries of the JSON-LD use other names than
+	// the page, like CONTRACTOR for Contract, so they are taken from the page
+	// only. Its location lacks the country, so it only stands in for the one
+	// of the page.
+	cssApplicantCount, _ := extractApplicantsCount(cleanText(doc.Find(".num-applicants__caption").First()))
+	applicantCount := pick(sources, "applicantCount", fromCSS(cssApplicantCount))
+	applyURL := pick(sources, "applyURL", fromCSS(extractApplyURL(doc.Find("code#applyUrl").First())))
+	companyLinkedInURL := pick(sources, "companyLinkedInURL", fromJSONLD(cleanURL(posting.str("hiringOrganization", "sameAs"))), fromCSS(cssCompanyLinkedInURL))
+	companyName := pick(sources, "companyName", fromJSONLD(posting.str("hiringOrganization", "name")), fromCSS(cleanText(doc.Find(".topcard__org-name-link").First())))
+	datePosted := pick(sources, "datePosted", fromJSONLD(posting.date("datePosted")), fromCSS(cleanText(doc.Find(".posted-time-ago__text").First())))
+	description := pick(sources, "description", fromJSONLD(htmlText(posting.str("description"))), fromCSS(blockText(doc.Find(".show-more-less-html__markup").First())))
+	easyApply := pick(sources, "easyApply", fromCSS(doc.Find("[data-tracking-control-name='public_jobs_apply-link-onsite']").Length() > 0))
+	employmentType := pick(sources, "employmentType", fromCSS(criteria["Employment type"]))
+	industries := pick(sources, "industries", fromCSS(criteria["Industries"]))
+	jobFunction := pick(sources, "jobFunction", fromCSS(criteria["Job function"]))
+	jobTitle := pick(sources, "jobTitle", fromJSONLD(posting.str("title")), fromCSS(cleanText(doc.Find(".top-card-layout__title").First())))
+	location := pick(sources, "location", fromCSS(cleanText(doc.Find(".topcard__flavor--bullet").First())), fromJSONLD(posting.place("jobLocation", "address")))
+	salary := pick(sources, "salary", fromCSS(cleanText(doc.Find(".compensation__salary").First())))
+	seniorityLevel := pick(sources, "seniorityLevel", fromCSS(criteria["Seniority level"]))
+
+	// The guest jobPosting endpoint has neither structured data nor a
+	// canonical link, so the job link is rebuilt from the ID in the request
+	// URL then.
+	requestURN := jobID(req.URL.String())
+	var requestLink string
+	if requestURN != "" {
+		requestLink = jobViewURL + requestURN
+	}
+	jobLink := pick(sources, "jobLink", fromOpenGraph(data.og("url")), fromCSS(doc.Find("link[rel='canonical']").AttrOr("href", "")), fromURL(requestLink))
+	jobURN := pick(sources, "jobURN", sourced[string]{sources["jobLink"], jobID(jobLink)}, fromURL(requestURN))
+
+	jobDetail = JobDetail{
+		ApplicantCount:     applicantCount,
+		ApplyURL:           applyURL,
+		CompanyLinkedInURL: companyLinkedInURL,
+		CompanyName:        companyName,
+		DatePosted:         datePosted,
+		Description:        description,
+		EasyApply:          easyApply,
+		EmploymentType:     employmentType,
+		Industries:         industries,
+		JobFunction:        jobFunction,
+		JobLink:            jobLink,
+		JobTitle:           jobTitle,
+		JobURN:             jobURN,
+		Location:           location,
+		Salary:             salary,
+		SeniorityLevel:     seniorityLevel,
+		Sources:            sources,
+	}
+
+	// Print the job for testing
+	if debug {
+		log.Printf("JobDetail: %+v", jobDetail)
+	}
+
+	if jobDetail.JobTitle == "" {
+		return &jobDetail, &ParseError{URL: req.URL.String(), Entity: "job"}
+	}
+	return &jobDetail, nil
+}
+
+// JobPostingURL returns the guest jobPosting endpoint of the job with the
+// given /jobs/view/ or jobPosting URL, or an error when link is neither.
+func JobPostingURL(link string) (string, error) {
+	id := jobID(link)
+	if id == "" {
+		return "", fmt.Errorf("no LinkedIn job ID in %s", link)
+	}
+	return "https://www.linkedin.com/jobs-guest/jobs/api/jobPosting/" + id, nil
+}
+
+// jobID returns the job ID in a /jobs/view/ or jobPosting URL, or an empty
+// string when link is neither.
+func jobID(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	match := jobIDPattern.FindStringSubmatch(u.Path)
+	if len(match) < 2 {
+		return ""
+	}
+	return match[1]
+}
+
+// extractApplyURL returns the employer site a job is applied on, from the
+// commented out externalApply link in s. Easy Apply jobs have no such link.
+func extractApplyURL(s *goquery.Selection) string {
+	comment, _ := s.Html()
+	link := strings.TrimSpace(comment)
+	link = strings.TrimPrefix(link, "<!--")
+	link = strings.TrimSuffix(link, "-->")
+	link = strings.Trim(strings.TrimSpace(link), `"`)
+	if link == "" {
+		return ""
+	}
+
+	// The externalApply link redirects to the employer site through LinkedIn.
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	if target := u.Query().Get("url"); target != "" {
+		return target
+	}
+	return link
+}
+
+// blockText returns the text of s with a line per paragraph, list item or
+// line break, and the white space within lines collapsed.
+func blockText(s *goquery.Selection) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			switch n.Data {
+			case "br", "div", "h1", "h2", "h3", "h4", "h5", "h6", "li", "ol", "p", "ul":
+				b.WriteString("\n")
+				defer b.WriteString("\n")
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, n := range s.Nodes {
+		walk(n)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// htmlText returns the blockText of the HTML escaped in s, as found in the
+// description of a JobPosting.
+func htmlText(s string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html.UnescapeString(s)))
+	if err != nil {
+		return ""
+	}
+	return blockText(doc.Find("body"))
+}
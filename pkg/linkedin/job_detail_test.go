@@ -0,0 +1,271 @@
+package linkedin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestGetJobDetailFromRequest(t *testing.T) {
+	// Define the test matrix
+	tests := []struct {
+		fileName               string
+		expectedApplicantCount int
+		expectedApplyURL       string
+		expectedCompanyURL     string
+		expectedDatePosted     string
+		expectedEasyApply      bool
+		expectedEmploymentType string
+		expectedIndustries     string
+		expectedJobTitle       string
+		expectedJobURN         string
+		expectedLocation       string
+		expectedSalary         string
+		expectedSeniorityLevel string
+	}{
+		{
+			"job-0.html",
+			133,
+			"",
+			"https://www.linkedin.com/company/computer-staff",
+			"2023-09-29T20:26:33.000Z",
+			true,
+			"Contract",
+			"Software Development and Oil and Gas",
+			"Senior DevOps Engineer, Istio service mesh",
+			"3726733564",
+			"Houston, TX",
+			"$85.00/hr - $100.00/hr",
+			"Mid-Senior level",
+		},
+		{
+			"job-1.html",
+			0,
+			"https://www.techfetch.com/job-description/istio-devops-engineer-iii-new-york-ny-j3597636&aid=cpclinkedin&utm_source=linkedin&utm_medium=cpc&utm_campaign=cpclinkedin",
+			"https://www.linkedin.com/company/techfetch",
+			"2023-09-30T08:11:06.000Z",
+			false,
+			"Part-time",
+			"IT Services and IT Consulting",
+			"Istio/DevOps Engineer III",
+			"3730748448",
+			"New York, NY",
+			"",
+			"Mid-Senior level",
+		},
+		{
+			"job-2.html",
+			46,
+			"",
+			"https://www.linkedin.com/company/inspyrsolutions",
+			"2023-09-27T19:10:10.000Z",
+			true,
+			"Contract",
+			"Staffing and Recruiting and Oil and Gas",
+			"Istio Service Mesh Engineer",
+			"3726202039",
+			"Houston, TX",
+			"",
+			"Mid-Senior level",
+		},
+		{
+			"job-3.html",
+			0,
+			"https://www.adzuna.co.uk/jobs/details/4323013147?v=027B846D43F4A3E881B5CED374B39385989BE358&ccd=273a6abc204c1cabdaf3fae635725621&frd=a2e2da566f3e26f8d2f74e136ddb57c4&r=14487462&utm_source=linkedin3&utm_medium=organic&chnlid=1936&a=e",
+			"https://uk.linkedin.com/company/norton-blake",
+			"2023-09-20T17:53:56.000Z",
+			false,
+			"Full-time",
+			"Staffing and Recruiting",
+			"DevOps Engineer, Istio, Service Mesh",
+			"3723262442",
+			"London, England, United Kingdom",
+			"",
+			"Entry level",
+		},
+		{
+			"job-4.html",
+			0,
+			"",
+			"https://www.linkedin.com/company/dice",
+			"4 days ago",
+			false,
+			"Full-time",
+			"Technology, Information and Internet",
+			"Senior Istio DevOps Engineer III",
+			"3729491611",
+			"Houston, TX",
+			"",
+			"Mid-Senior level",
+		},
+		{
+			"job-5.html",
+			0,
+			"https://click.appcast.io/track/hm5gcm1?cs=ivj&sjg=6ijf",
+			"https://www.linkedin.com/company/dice",
+			"2023-09-30T10:42:28.000Z",
+			false,
+			"Full-time",
+			"Technology, Information and Internet",
+			"Senior Istio DevOps Engineer III - ONSITE in HOUSTON",
+			"3730757253",
+			"Houston, TX",
+			"",
+			"Mid-Senior level",
+		},
+		{
+			"job-6.html",
+			0,
+			"https://www.timesjobs.com/candidate/JobDetailView.html?from=submit&adId=66227101&bc=EXT&sequence=0&counter=1&utm_source=LinkedIn&utm_medium=referral&utm_campaign=LI_Feed&siteparams=301p",
+			"https://www.linkedin.com/company/cotocus",
+			"2023-06-30T13:02:45.000Z",
+			false,
+			"Full-time",
+			"Staffing and Recruiting",
+			"Istio Engineer",
+			"3674652557",
+			"Bengaluru, Karnataka, India",
+			"",
+			"Entry level",
+		},
+	}
+
+	// Directory containing test HTML files
+	_, filename, _, _ := runtime.Caller(0)
+	basepath := filepath.Dir(filename)
+	testDir := filepath.Join(basepath, "../..", "testdata", "job")
+
+	// Start a local HTTP server to serve the test files
+	server, addr := startLocalHTTPServer(testDir)
+	defer server.Close()
+
+	// Iterate over the test matrix
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			jobUrl := fmt.Sprintf("http://%s/%s", addr, tt.fileName)
+			req, err := http.NewRequest("GET", jobUrl, nil)
+			if err != nil {
+				t.Fatalf("Error creating HTTP request: %v", err)
+			}
+			job, err := getJobDetailFromRequest(NewScrapeClient(), req, false)
+			if err != nil {
+				t.Fatalf("Error in getJobDetailFromRequest for file %s: %s", tt.fileName, err)
+			}
+
+			if job.ApplicantCount != tt.expectedApplicantCount {
+				t.Errorf("Expected job.ApplicantCount set %d for file %s, but got %d", tt.expectedApplicantCount, tt.fileName, job.ApplicantCount)
+			}
+			if job.ApplyURL != tt.expectedApplyURL {
+				t.Errorf("Expected job.ApplyURL set %q for file %s, but got %q", tt.expectedApplyURL, tt.fileName, job.ApplyURL)
+			}
+			if job.CompanyLinkedInURL != tt.expectedCompanyURL {
+				t.Errorf("Expected job.CompanyLinkedInURL set %q for file %s, but got %q", tt.expectedCompanyURL, tt.fileName, job.CompanyLinkedInURL)
+			}
+			if job.DatePosted != tt.expectedDatePosted {
+				t.Errorf("Expected job.DatePosted set %q for file %s, but got %q", tt.expectedDatePosted, tt.fileName, job.DatePosted)
+			}
+			if job.EasyApply != tt.expectedEasyApply {
+				t.Errorf("Expected job.EasyApply set %v for file %s, but got %v", tt.expectedEasyApply, tt.fileName, job.EasyApply)
+			}
+			if job.EmploymentType != tt.expectedEmploymentType {
+				t.Errorf("Expected job.EmploymentType set %q for file %s, but got %q", tt.expectedEmploymentType, tt.fileName, job.EmploymentType)
+			}
+			if job.Industries != tt.expectedIndustries {
+				t.Errorf("Expected job.Industries set %q for file %s, but got %q", tt.expectedIndustries, tt.fileName, job.Industries)
+			}
+			if job.JobFunction != "Engineering and Information Technology" {
+				t.Errorf("Expected job.JobFunction set %q for file %s, but got %q", "Engineering and Information Technology", tt.fileName, job.JobFunction)
+			}
+			if job.JobTitle != tt.expectedJobTitle {
+				t.Errorf("Expected job.JobTitle set %q for file %s, but got %q", tt.expectedJobTitle, tt.fileName, job.JobTitle)
+			}
+			if job.JobURN != tt.expectedJobURN {
+				t.Errorf("Expected job.JobURN set %q for file %s, but got %q", tt.expectedJobURN, tt.fileName, job.JobURN)
+			}
+			if !strings.HasSuffix(job.JobLink, "-"+tt.expectedJobURN) {
+				t.Errorf("Expected job.JobLink ending in the job URN for file %s, but got %q", tt.fileName, job.JobLink)
+			}
+			if job.Location != tt.expectedLocation {
+				t.Errorf("Expected job.Location set %q for file %s, but got %q", tt.expectedLocation, tt.fileName, job.Location)
+			}
+			if job.Salary != tt.expectedSalary {
+				t.Errorf("Expected job.Salary set %q for file %s, but got %q", tt.expectedSalary, tt.fileName, job.Salary)
+			}
+			if job.SeniorityLevel != tt.expectedSeniorityLevel {
+				t.Errorf("Expected job.SeniorityLevel set %q for file %s, but got %q", tt.expectedSeniorityLevel, tt.fileName, job.SeniorityLevel)
+			}
+			if job.Description == "" || strings.Contains(job.Description, "\n\n") {
+				t.Errorf("Expected job.Description set with a line per paragraph for file %s, but got %q", tt.fileName, job.Description)
+			}
+		})
+	}
+}
+
+func TestGetJobDetailFromJobPosting(t *testing.T) {
+	// The guest jobPosting endpoint serves the job without the page around
+	// it, so there is neither structured data nor a canonical link to take
+	// the job link from.
+	_, filename, _, _ := runtime.Caller(0)
+	basepath := filepath.Dir(filename)
+	page, err := os.ReadFile(filepath.Join(basepath, "../..", "testdata", "job", "job-2.html"))
+	if err != nil {
+		t.Fatalf("Error reading job-2.html: %v", err)
+	}
+	posting := strings.NewReplacer(
+		`<link rel="canonical"`, `<link rel="alternate"`,
+		`property="og:url"`, `property="og:see_also"`,
+		`application/ld+json`, `application/json`,
+	).Replace(string(page))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jobs-guest/jobs/api/jobPosting/3726202039" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, posting)
+	}))
+	defer server.Close()
+
+	job, err := GetJobDetailFromUrl(NewScrapeClient(), server.URL+"/jobs-guest/jobs/api/jobPosting/3726202039", false)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if job.JobURN != "3726202039" || job.JobLink != "https://www.linkedin.com/jobs/view/3726202039" {
+		t.Errorf("Expected the job URN and link from the jobPosting URL, but got %q and %q", job.JobURN, job.JobLink)
+	}
+	if job.JobTitle != "Istio Service Mesh Engineer" {
+		t.Errorf("Expected job title %q, but got %q", "Istio Service Mesh Engineer", job.JobTitle)
+	}
+	if job.Sources["jobLink"] != SourceURL || job.Sources["jobTitle"] != SourceCSS || job.Sources["datePosted"] != SourceCSS {
+		t.Errorf("Expected the job link from the URL and the rest from the markup, but got sources %v", job.Sources)
+	}
+}
+
+func TestJobPostingURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		hasError bool
+	}{
+		{"https://www.linkedin.com/jobs/view/istio-engineer-at-cotocus-3674652557", "https://www.linkedin.com/jobs-guest/jobs/api/jobPosting/3674652557", false},
+		{"https://in.linkedin.com/jobs/view/istio-engineer-at-cotocus-3674652557/?trk=public_jobs", "https://www.linkedin.com/jobs-guest/jobs/api/jobPosting/3674652557", false},
+		{"https://www.linkedin.com/jobs/view/3674652557", "https://www.linkedin.com/jobs-guest/jobs/api/jobPosting/3674652557", false},
+		{"https://www.linkedin.com/jobs-guest/jobs/api/jobPosting/3674652557", "https://www.linkedin.com/jobs-guest/jobs/api/jobPosting/3674652557", false},
+		{"https://www.linkedin.com/company/cotocus", "", true},
+	}
+
+	for _, test := range tests {
+		result, err := JobPostingURL(test.input)
+		if test.hasError && err == nil {
+			t.Errorf("Expected error for input %s, but got none", test.input)
+		}
+		if !test.hasError && result != test.expected {
+			t.Errorf("For input %s, expected %s, but got %s", test.input, test.expected, result)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package linkedin
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ExperienceLevel is the experience level a job asks for (f_E).
+type ExperienceLevel string
+
+const (
+	LevelInternship ExperienceLevel = "internship"
+	LevelEntry      ExperienceLevel = "entry"
+	LevelAssociate  ExperienceLevel = "associate"
+	LevelMidSenior  ExperienceLevel = "senior"
+	LevelDirector   ExperienceLevel = "director"
+	LevelExecutive  ExperienceLevel = "executive"
+)
+
+var experienceLevelParams = map[ExperienceLevel]string{
+	LevelInternship: "1",
+	LevelEntry:      "2",
+	LevelAssociate:  "3",
+	LevelMidSenior:  "4",
+	LevelDirector:   "5",
+	LevelExecutive:  "6",
+}
+
+// SetExperienceLevel sets the experience level from a string, handling
+// case-insensitivity.
+func SetExperienceLevel(s string) (ExperienceLevel, error) {
+	switch strings.ToLower(s) {
+	case "internship":
+		return LevelInternship, nil
+	case "entry", "entry-level":
+		return LevelEntry, nil
+	case "associate":
+		return LevelAssociate, nil
+	case "senior", "mid-senior":
+		return LevelMidSenior, nil
+	case "director":
+		return LevelDirector, nil
+	case "executive":
+		return LevelExecutive, nil
+	default:
+		return "", fmt.Errorf("unknown experience level: %s", s)
+	}
+}
+
+// JobType is the type of employment of a job (f_JT).
+type JobType string
+
+const (
+	JobTypeFullTime   JobType = "full-time"
+	JobTypePartTime   JobType = "part-time"
+	JobTypeContract   JobType = "contract"
+	JobTypeTemporary  JobType = "temporary"
+	JobTypeVolunteer  JobType = "volunteer"
+	JobTypeInternship JobType = "internship"
+	JobTypeOther      JobType = "other"
+)
+
+var jobTypeParams = map[JobType]string{
+	JobTypeFullTime:   "F",
+	JobTypePartTime:   "P",
+	JobTypeContract:   "C",
+	JobTypeTemporary:  "T",
+	JobTypeVolunteer:  "V",
+	JobTypeInternship: "I",
+	JobTypeOther:      "O",
+}
+
+// SetJobType sets the job type from a string, handling case-insensitivity.
+func SetJobType(s string) (JobType, error) {
+	jobType := JobType(strings.ToLower(s))
+	if _, ok := jobTypeParams[jobType]; !ok {
+		return "", fmt.Errorf("unknown job type: %s", s)
+	}
+	return jobType, nil
+}
+
+// Workplace is where a job is done (f_WT).
+type Workplace string
+
+const (
+	WorkplaceOnSite Workplace = "on-site"
+	WorkplaceRemote Workplace = "remote"
+	WorkplaceHybrid Workplace = "hybrid"
+)
+
+var workplaceParams = map[Workplace]string{
+	WorkplaceOnSite: "1",
+	WorkplaceRemote: "2",
+	WorkplaceHybrid: "3",
+}
+
+// JobSort is the order of job search results (sortBy).
+type JobSort string
+
+const (
+	SortByRelevance JobSort = "relevance"
+	SortByDate      JobSort = "date"
+)
+
+var jobSortParams = map[JobSort]string{
+	SortByRelevance: "R",
+	SortByDate:      "DD",
+}
+
+// SetJobSort sets the job search order from a string, handling
+// case-insensitivity.
+func SetJobSort(s string) (JobSort, error) {
+	sort := JobSort(strings.ToLower(s))
+	if _, ok := jobSortParams[sort]; !ok {
+		return "", fmt.Errorf("unknown sort order: %s", s)
+	}
+	return sort, nil
+}
+
+// SetPostedWithin sets the recency of jobs from a string like 24h, week or
+// month. LinkedIn has no filter for the last year, so year is not accepted.
+func SetPostedWithin(s string) (Recency, error) {
+	switch strings.ToLower(s) {
+	case "any":
+		return RecencyAny, nil
+	case "24h", "day":
+		return RecencyDay, nil
+	case "7d", "week":
+		return RecencyWeek, nil
+	case "30d", "month":
+		return RecencyMonth, nil
+	default:
+		return "", fmt.Errorf("unknown posted within: %s", s)
+	}
+}
+
+// JobFilters are the filters of the LinkedIn job search. The zero value
+// filters nothing.
+type JobFilters struct {
+	// Posted only finds jobs posted within the period. It overrides the
+	// posted: field of the keywords.
+	Posted Recency
+	// Levels, JobTypes and Workplaces find jobs matching any of the values.
+	Levels     []ExperienceLevel
+	JobTypes   []JobType
+	Workplaces []Workplace
+	// CompanyIDs only finds jobs of the companies with the numeric IDs.
+	CompanyIDs []string
+	// GeoID is the numeric LinkedIn ID of the location, which is more
+	// precise than its name.
+	GeoID string
+	// Distance is the radius around the location in miles.
+	Distance int
+	// EasyApply only finds jobs that can be applied to on LinkedIn.
+	EasyApply bool
+	// FewApplicants only finds jobs with fewer than 10 applicants.
+	FewApplicants bool
+	Sort          JobSort
+}
+
+// Validate reports the first filter that LinkedIn would not understand.
+func (f JobFilters) Validate() error {
+	if _, ok := jobsRecency[f.Posted]; !ok && f.Posted != RecencyAny {
+		return fmt.Errorf("unknown posted within: %s", f.Posted)
+	}
+	for _, level := range f.Levels {
+		if _, ok := experienceLevelParams[level]; !ok {
+			return fmt.Errorf("unknown experience level: %s", level)
+		}
+	}
+	for _, jobType := range f.JobTypes {
+		if _, ok := jobTypeParams[jobType]; !ok {
+			return fmt.Errorf("unknown job type: %s", jobType)
+		}
+	}
+	for _, workplace := range f.Workplaces {
+		if _, ok := workplaceParams[workplace]; !ok {
+			return fmt.Errorf("unknown workplace: %s", workplace)
+		}
+	}
+	for _, id := range f.CompanyIDs {
+		if !isNumericID(id) {
+			return fmt.Errorf("company ID should be numeric: %s", id)
+		}
+	}
+	if f.GeoID != "" && !isNumericID(f.GeoID) {
+		return fmt.Errorf("geo ID should be numeric: %s", f.GeoID)
+	}
+	if f.Distance < 0 {
+		return fmt.Errorf("distance should not be negative: %d", f.Distance)
+	}
+	if _, ok := jobSortParams[f.Sort]; !ok && f.Sort != "" {
+		return fmt.Errorf("unknown sort order: %s", f.Sort)
+	}
+	return nil
+}
+
+// addParams adds the parameters of the filters to params. Jobs posted within
+// posted are found when the filters have no recency of their own.
+func (f JobFilters) addParams(params url.Values, posted Recency) {
+	if f.Posted != RecencyAny {
+		posted = f.Posted
+	}
+	if tpr, ok := jobsRecency[posted]; ok {
+		params.Add("f_TPR", tpr)
+	}
+	addListParam(params, "f_E", f.Levels, experienceLevelParams)
+	addListParam(params, "f_JT", f.JobTypes, jobTypeParams)
+	addListParam(params, "f_WT", f.Workplaces, workplaceParams)
+	if len(f.CompanyIDs) > 0 {
+		params.Add("f_C", strings.Join(f.CompanyIDs, ","))
+	}
+	if f.GeoID != "" {
+		params.Add("geoId", f.GeoID)
+	}
+	if f.Distance > 0 {
+		params.Add("distance", strconv.Itoa(f.Distance))
+	}
+	if f.EasyApply {
+		params.Add("f_AL", "true")
+	}
+	if f.FewApplicants {
+		params.Add("f_JIYN", "true")
+	}
+	if sort, ok := jobSortParams[f.Sort]; ok {
+		params.Add("sortBy", sort)
+	}
+}
+
+// addListParam adds the LinkedIn codes of values to params as a single comma
+// separated parameter, which LinkedIn reads as any of them.
+func addListParam[T comparable](params url.Values, name string, values []T, codes map[T]string) {
+	if len(values) == 0 {
+		return
+	}
+	list := make([]string, len(values))
+	for i, value := range values {
+		list[i] = codes[value]
+	}
+	params.Add(name, strings.Join(list, ","))
+}
+
+func isNumericID(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
+
+// JobSearchOption configures a job search.
+type JobSearchOption func(*jobSearch)
+
+type jobSearch struct {
+	filters     JobFilters
+	maxPages    int
+	maxResults  int
+	queryCounts func(JobQueryCount)
+
+	results int
+}
+
+// WithJobFilters narrows the job search down with filters.
+func WithJobFilters(filters JobFilters) JobSearchOption {
+	return func(s *jobSearch) {
+		s.filters = filters
+	}
+}
+
+// WithMaxPages fetches at most pages result pages per query of the job
+// search. 0 fetches every page LinkedIn serves.
+func WithMaxPages(pages int) JobSearchOption {
+	return func(s *jobSearch) {
+		s.maxPages = pages
+	}
+}
+
+// WithMaxResults stops the job search once it found results jobs, including
+// the jobs resumed from a checkpoint. 0 finds every job.
+func WithMaxResults(results int) JobSearchOption {
+	return func(s *jobSearch) {
+		s.maxResults = results
+	}
+}
+
+// maxQueryPages returns the number of result pages to fetch at most per
+// query.
+func (s *jobSearch) maxQueryPages() int {
+	pages := maxJobsOffset/jobsPageSize + 1
+	if s.maxPages > 0 && s.maxPages < pages {
+		return s.maxPages
+	}
+	return pages
+}
+
+// full reports whether the search found the maximum number of results.
+func (s *jobSearch) full() bool {
+	return s.maxResults > 0 && s.results >= s.maxResults
+}
+
+func newJobSearch(opts []JobSearchOption) *jobSearch {
+	s := &jobSearch{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
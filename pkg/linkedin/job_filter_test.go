@@ -0,0 +1,126 @@
+package linkedin
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSetJobFilterValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		set      func(string) (string, error)
+		input    string
+		expected string
+		hasError bool
+	}{
+		{"posted", setString(SetPostedWithin), "24h", "day", false},
+		{"posted", setString(SetPostedWithin), "Week", "week", false},
+		{"posted", setString(SetPostedWithin), "any", "", false},
+		{"posted", setString(SetPostedWithin), "year", "", true},
+		{"level", setString(SetExperienceLevel), "Senior", "senior", false},
+		{"level", setString(SetExperienceLevel), "mid-senior", "senior", false},
+		{"level", setString(SetExperienceLevel), "entry-level", "entry", false},
+		{"level", setString(SetExperienceLevel), "principal", "", true},
+		{"job type", setString(SetJobType), "Full-Time", "full-time", false},
+		{"job type", setString(SetJobType), "freelance", "", true},
+		{"sort", setString(SetJobSort), "DATE", "date", false},
+		{"sort", setString(SetJobSort), "newest", "", true},
+	}
+
+	for _, tt := range tests {
+		result, err := tt.set(tt.input)
+		if tt.hasError && err == nil {
+			t.Errorf("Expected error for %s %q, but got none", tt.name, tt.input)
+		}
+		if !tt.hasError && (err != nil || result != tt.expected) {
+			t.Errorf("Expected %s %q for %q, but got %q (%v)", tt.name, tt.expected, tt.input, result, err)
+		}
+	}
+}
+
+func setString[T ~string](set func(string) (T, error)) func(string) (string, error) {
+	return func(s string) (string, error) {
+		v, err := set(s)
+		return string(v), err
+	}
+}
+
+func TestJobSearchURLFilters(t *testing.T) {
+	q := mustParseQuery(t, "devops posted:month")
+
+	tests := []struct {
+		name     string
+		filters  JobFilters
+		expected map[string]string
+	}{
+		{
+			"no filters",
+			JobFilters{},
+			map[string]string{"f_TPR": "r2592000", "f_E": "", "f_WT": "", "sortBy": ""},
+		},
+		{
+			"all filters",
+			JobFilters{
+				Posted:        RecencyDay,
+				Levels:        []ExperienceLevel{LevelMidSenior, LevelDirector},
+				JobTypes:      []JobType{JobTypeFullTime, JobTypeContract},
+				Workplaces:    []Workplace{WorkplaceRemote, WorkplaceHybrid},
+				CompanyIDs:    []string{"1035", "1441"},
+				GeoID:         "100565514",
+				Distance:      25,
+				EasyApply:     true,
+				FewApplicants: true,
+				Sort:          SortByDate,
+			},
+			map[string]string{
+				"f_TPR":    "r86400",
+				"f_E":      "4,5",
+				"f_JT":     "F,C",
+				"f_WT":     "2,3",
+				"f_C":      "1035,1441",
+				"geoId":    "100565514",
+				"distance": "25",
+				"f_AL":     "true",
+				"f_JIYN":   "true",
+				"sortBy":   "DD",
+				"keywords": "devops",
+				"start":    "50",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(jobSearchURL("Belgium", q, tt.filters, 50))
+		for param, expected := range tt.expected {
+			if value := u.Query().Get(param); value != expected {
+				t.Errorf("Expected %s=%q for %s, but got %q", param, expected, tt.name, value)
+			}
+		}
+	}
+}
+
+func TestJobFiltersValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  JobFilters
+		hasError bool
+	}{
+		{"zero value", JobFilters{}, false},
+		{"known values", JobFilters{Levels: []ExperienceLevel{LevelEntry}, Workplaces: []Workplace{WorkplaceOnSite}, CompanyIDs: []string{"1035"}}, false},
+		{"unknown level", JobFilters{Levels: []ExperienceLevel{"principal"}}, true},
+		{"year recency", JobFilters{Posted: RecencyYear}, true},
+		{"named company", JobFilters{CompanyIDs: []string{"microsoft"}}, true},
+		{"named geo", JobFilters{GeoID: "Belgium"}, true},
+		{"negative distance", JobFilters{Distance: -1}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.filters.Validate()
+		if tt.hasError && err == nil {
+			t.Errorf("Expected error for %s, but got none", tt.name)
+		}
+		if !tt.hasError && err != nil {
+			t.Errorf("Expected no error for %s, but got %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package linkedin
+
+// JobQuery is a single search of a job search: one region and one keywords
+// entry. A job search runs every combination of its regions and keywords as
+// a JobQuery of its own, as LinkedIn reads a comma separated location as a
+// single place.
+type JobQuery struct {
+	Region   string
+	Keywords string
+
+	query *Query
+}
+
+// String returns the query as "region: keywords", as jobs are tagged with it.
+func (q JobQuery) String() string {
+	if q.query == nil {
+		return q.Region + ": " + q.Keywords
+	}
+	return q.Region + ": " + q.query.String()
+}
+
+// JobQueryCount is the number of jobs a JobQuery found, and how many of them
+// no earlier query of the search found. Total is the number of results
+// LinkedIn reported for the query, or 0 when it did not.
+type JobQueryCount struct {
+	Query JobQuery
+	Found int
+	New   int
+	Total int
+}
+
+// jobQueries returns a JobQuery for every combination of regions and
+// keywords, region by region. No regions or no keywords search without them.
+func jobQueries(regions []string, keywords []string) ([]JobQuery, error) {
+	if len(regions) == 0 {
+		regions = []string{""}
+	}
+	if len(keywords) == 0 {
+		keywords = []string{""}
+	}
+
+	parsed := make([]*Query, len(keywords))
+	for i, keyword := range keywords {
+		query, err := ParseQuery(keyword)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = query
+	}
+
+	queries := make([]JobQuery, 0, len(regions)*len(keywords))
+	for _, region := range regions {
+		for i, keyword := range keywords {
+			queries = append(queries, JobQuery{Region: region, Keywords: keyword, query: parsed[i]})
+		}
+	}
+	return queries, nil
+}
+
+// WithQueryCounts calls report with the counts of every JobQuery once it is
+// done, or once the search stops during it.
+func WithQueryCounts(report func(JobQueryCount)) JobSearchOption {
+	return func(s *jobSearch) {
+		s.queryCounts = report
+	}
+}
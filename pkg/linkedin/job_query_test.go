@@ -0,0 +1,114 @@
+package linkedin
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestJobQueries(t *testing.T) {
+	queries, err := jobQueries([]string{"Belgium", "Netherlands"}, []string{"golang posted:week", `"site reliability"`})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	expected := []string{
+		"Belgium: golang posted:week",
+		`Belgium: "site reliability"`,
+		"Netherlands: golang posted:week",
+		`Netherlands: "site reliability"`,
+	}
+	if len(queries) != len(expected) {
+		t.Fatalf("Expected %d queries, but got %d", len(expected), len(queries))
+	}
+	for i, query := range queries {
+		if query.String() != expected[i] {
+			t.Errorf("Expected query %d to be %q, but got %q", i, expected[i], query.String())
+		}
+	}
+
+	if _, err := jobQueries([]string{"Belgium"}, []string{"(golang"}); err == nil {
+		t.Errorf("Expected error for an invalid query, but got none")
+	}
+}
+
+func TestStreamJobsOnlineQueryMatrix(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	basepath := filepath.Dir(filename)
+	firstPage, err := os.ReadFile(filepath.Join(basepath, "../..", "testdata", "job-search", "jobs-0.html"))
+	if err != nil {
+		t.Fatalf("Error reading jobs-0.html: %v", err)
+	}
+	finalPage, err := os.ReadFile(filepath.Join(basepath, "../..", "testdata", "job-search", "jobs-final.html"))
+	if err != nil {
+		t.Fatalf("Error reading jobs-final.html: %v", err)
+	}
+
+	// The second keywords find the same jobs as the first ones, so all of
+	// them are duplicates.
+	regions := []string{"Belgium", "Netherlands"}
+	keywords := []string{"golang", "rust"}
+	queries, _ := jobQueries(regions, keywords)
+	pages := map[string]string{
+		jobSearchURL("Belgium", queries[0].query, JobFilters{}, 0):     string(firstPage),
+		jobSearchURL("Belgium", queries[0].query, JobFilters{}, 25):    string(finalPage),
+		jobSearchURL("Belgium", queries[0].query, JobFilters{}, 50):    "",
+		jobSearchURL("Belgium", queries[1].query, JobFilters{}, 0):     string(firstPage),
+		jobSearchURL("Belgium", queries[1].query, JobFilters{}, 25):    "",
+		jobSearchURL("Netherlands", queries[2].query, JobFilters{}, 0): "",
+		jobSearchURL("Netherlands", queries[3].query, JobFilters{}, 0): "",
+	}
+	checkpoint, err := OpenCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"), "job search")
+	if err != nil {
+		t.Fatalf("Error opening checkpoint: %v", err)
+	}
+
+	var counts []JobQueryCount
+	client := NewScrapeClient(WithHARReplayer(newJobSearchReplayer(t, pages)), WithCheckpoint(checkpoint))
+	jobs, err := SearchJobsOnline(client, regions, keywords, false, WithQueryCounts(func(count JobQueryCount) {
+		counts = append(counts, count)
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if len(jobs) != 41 {
+		t.Errorf("Expected 41 jobs, but got %d", len(jobs))
+	}
+	seen := make(map[string]bool)
+	for _, job := range jobs {
+		if seen[job.JobURN] {
+			t.Errorf("Expected every job once, but got %s twice", job.JobURN)
+		}
+		seen[job.JobURN] = true
+		if job.Query != "Belgium: golang" {
+			t.Errorf("Expected job %s tagged with the first query, but got %q", job.JobURN, job.Query)
+		}
+	}
+
+	expectedCounts := []struct{ found, new int }{{41, 41}, {25, 0}, {0, 0}, {0, 0}}
+	if len(counts) != len(expectedCounts) {
+		t.Fatalf("Expected %d query counts, but got %d", len(expectedCounts), len(counts))
+	}
+	for i, count := range counts {
+		if count.Found != expectedCounts[i].found || count.New != expectedCounts[i].new {
+			t.Errorf("Expected query %q to find %d jobs, %d new, but got %d, %d new", count.Query.String(), expectedCounts[i].found, expectedCounts[i].new, count.Found, count.New)
+		}
+	}
+
+	// Resuming from the checkpoint fetches nothing, not even the pages that
+	// only held duplicates.
+	resumed, err := OpenCheckpoint(checkpoint.Path(), "job search")
+	if err != nil {
+		t.Fatalf("Error resuming checkpoint: %v", err)
+	}
+	client = NewScrapeClient(WithHARReplayer(newJobSearchReplayer(t, nil)), WithCheckpoint(resumed))
+	jobs, err = SearchJobsOnline(client, regions, keywords, false)
+	if err != nil {
+		t.Fatalf("Expected no error resuming, but got %v", err)
+	}
+	if len(jobs) != 0 {
+		t.Errorf("Expected no new jobs resuming, but got %d", len(jobs))
+	}
+}
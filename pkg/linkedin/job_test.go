@@ -1,11 +1,15 @@
 package linkedin
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -25,13 +29,14 @@ func TestJobCsvContent(t *testing.T) {
 				JobTitle:           "Software|Engineer",
 				JobURN:             "urn:li:job:123456",
 				Location:           "San Francisco, CA",
+				Query:              "San Francisco: golang",
 			},
-			expected: "https://linkedin.com/company/techcorp|Techcorp|2023-01-01|https://linkedin.com/jobs/view/123456|Software Engineer|urn:li:job:123456|San Francisco, CA",
+			expected: "https://linkedin.com/company/techcorp|Techcorp|2023-01-01|https://linkedin.com/jobs/view/123456|Software Engineer|urn:li:job:123456|San Francisco, CA|San Francisco: golang",
 		},
 		{
 			name:     "empty job",
 			job:      Job{},
-			expected: "||||||",
+			expected: "|||||||",
 		},
 	}
 
@@ -47,7 +52,7 @@ func TestJobCsvContent(t *testing.T) {
 
 func TestJobCsvHeader(t *testing.T) {
 	j := Job{}
-	expected := "companyLinkedInURL|companyName|datePosted|jobLink|jobTitle|jobURN|location"
+	expected := "companyLinkedInURL|companyName|datePosted|jobLink|jobTitle|jobURN|location|query"
 	got := j.CsvHeader()
 	if got != expected {
 		t.Errorf("expected %q, got %q", expected, got)
@@ -70,6 +75,7 @@ func TestJobJson(t *testing.T) {
 				DatePosted:         "2023-01-01",
 				JobLink:            "https://linkedin.com/jobs/view/123456",
 				JobURN:             "urn:li:job:123456",
+				Query:              "San Francisco: golang",
 			},
 			expected: `{
   "companyLinkedInURL": "https://linkedin.com/company/techcorp",
@@ -78,7 +84,8 @@ func TestJobJson(t *testing.T) {
   "jobLink": "https://linkedin.com/jobs/view/123456",
   "jobTitle": "Software Engineer",
   "jobURN": "urn:li:job:123456",
-  "location": "San Francisco, CA"
+  "location": "San Francisco, CA",
+  "query": "San Francisco: golang"
 }`,
 		},
 		{
@@ -91,7 +98,8 @@ func TestJobJson(t *testing.T) {
   "jobLink": "",
   "jobTitle": "",
   "jobURN": "",
-  "location": ""
+  "location": "",
+  "query": ""
 }`,
 		},
 	}
@@ -134,7 +142,7 @@ func TestSearchJobsPerPage(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.fileName, func(t *testing.T) {
 			jobSearchURL := fmt.Sprintf("http://%s/%s", addr, tt.fileName)
-			jobs, err := GetJobsFromSearchUrl(jobSearchURL, false)
+			jobs, err := GetJobsFromSearchUrl(NewScrapeClient(), jobSearchURL, false)
 			if err != nil {
 				t.Fatalf("Error in GetJobsFromSearchUrl for file %s: %s", tt.fileName, err)
 			}
@@ -173,3 +181,187 @@ func startLocalHTTPServer(dir string) (*http.Server, string) {
 
 	return server, listener.Addr().String()
 }
+
+func TestSearchJobsOnlineContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	jobs, err := SearchJobsOnlineContext(ctx, NewScrapeClient(), []string{"Belgium"}, []string{"golang"}, false)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, but got %v", err)
+	}
+	if len(jobs) != 0 {
+		t.Errorf("Expected no jobs, but got %d", len(jobs))
+	}
+}
+
+// newJobSearchReplayer serves pages, mapping search URLs to result pages.
+// Other URLs fail with ErrNotRecorded.
+func newJobSearchReplayer(t *testing.T, pages map[string]string) *HARReplayer {
+	t.Helper()
+	har := &HAR{}
+	for url, page := range pages {
+		har.Log.Entries = append(har.Log.Entries, HAREntry{
+			Request:  HARRequest{Method: "GET", URL: url},
+			Response: HARResponse{Status: 200, Content: HARContent{MimeType: "text/html", Text: page}},
+		})
+	}
+	replayer, err := NewHARReplayer(har)
+	if err != nil {
+		t.Fatalf("Error creating replayer: %v", err)
+	}
+	return replayer
+}
+
+func TestStreamJobsOnlinePaging(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	basepath := filepath.Dir(filename)
+	firstPage, err := os.ReadFile(filepath.Join(basepath, "../..", "testdata", "job-search", "jobs-0.html"))
+	if err != nil {
+		t.Fatalf("Error reading jobs-0.html: %v", err)
+	}
+	finalPage, err := os.ReadFile(filepath.Join(basepath, "../..", "testdata", "job-search", "jobs-final.html"))
+	if err != nil {
+		t.Fatalf("Error reading jobs-final.html: %v", err)
+	}
+	// The search page tells the number of results, and has list items other
+	// than jobs.
+	searchPage := strings.Replace(string(firstPage), "<body>", `<body><ul><li><a href="/jobs">Jobs</a></li></ul><span class="results-context-header__job-count">41</span>`, 1)
+
+	authWall := `<html><head><title>Sign Up | LinkedIn</title></head></html>`
+
+	query := mustParseQuery(t, "istio")
+	page := func(offset int) string {
+		return jobSearchURL("Belgium", query, JobFilters{}, offset)
+	}
+
+	// Pages missing from a test fail the search, so every test fetches only
+	// the pages it lists.
+	tests := []struct {
+		name          string
+		pages         map[string]string
+		opts          []JobSearchOption
+		expectedJobs  int
+		expectedTotal int
+	}{
+		{"total plans the pages", map[string]string{page(0): searchPage, page(25): string(finalPage)}, nil, 41, 41},
+		{"repeated page", map[string]string{page(0): string(firstPage), page(25): string(firstPage)}, nil, 25, 0},
+		{"max pages", map[string]string{page(0): searchPage}, []JobSearchOption{WithMaxPages(1)}, 25, 41},
+		{"max results", map[string]string{page(0): searchPage, page(25): string(finalPage)}, []JobSearchOption{WithMaxResults(30)}, 30, 41},
+		{"blocked search page", map[string]string{page(0): authWall, guestJobSearchURL("Belgium", query, JobFilters{}, 0): string(firstPage), page(25): string(finalPage), page(50): ""}, nil, 41, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var counts []JobQueryCount
+			opts := append(tt.opts, WithQueryCounts(func(count JobQueryCount) {
+				counts = append(counts, count)
+			}))
+			client := NewScrapeClient(WithHARReplayer(newJobSearchReplayer(t, tt.pages)))
+			jobs, err := SearchJobsOnline(client, []string{"Belgium"}, []string{"istio"}, false, opts...)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+			if len(jobs) != tt.expectedJobs {
+				t.Errorf("Expected %d jobs, but got %d", tt.expectedJobs, len(jobs))
+			}
+			if len(counts) != 1 || counts[0].Total != tt.expectedTotal {
+				t.Errorf("Expected a total of %d jobs, but got %v", tt.expectedTotal, counts)
+			}
+		})
+	}
+}
+
+func TestStreamJobsOnlineResumesCounts(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	basepath := filepath.Dir(filename)
+	firstPage, err := os.ReadFile(filepath.Join(basepath, "../..", "testdata", "job-search", "jobs-0.html"))
+	if err != nil {
+		t.Fatalf("Error reading jobs-0.html: %v", err)
+	}
+	finalPage, err := os.ReadFile(filepath.Join(basepath, "../..", "testdata", "job-search", "jobs-final.html"))
+	if err != nil {
+		t.Fatalf("Error reading jobs-final.html: %v", err)
+	}
+	searchPage := strings.Replace(string(firstPage), "<body>", `<body><span class="results-context-header__job-count">41</span>`, 1)
+
+	query := mustParseQuery(t, "istio")
+	page := func(offset int) string {
+		return jobSearchURL("Belgium", query, JobFilters{}, offset)
+	}
+	checkpoint, err := OpenCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"), "job search")
+	if err != nil {
+		t.Fatalf("Error opening checkpoint: %v", err)
+	}
+
+	// The second page is missing, which fails the search after the first one
+	// is completed in the checkpoint.
+	client := NewScrapeClient(WithHARReplayer(newJobSearchReplayer(t, map[string]string{page(0): searchPage})), WithCheckpoint(checkpoint))
+	jobs, err := SearchJobsOnline(client, []string{"Belgium"}, []string{"istio"}, false)
+	if err == nil {
+		t.Fatalf("Expected an error for the missing page, but got none")
+	}
+	if len(jobs) != 25 {
+		t.Errorf("Expected the 25 jobs of the first page along with the error, but got %d", len(jobs))
+	}
+
+	// The resumed search plans its pages from the total of the first page,
+	// so it fetches no page past the second one, and counts the jobs of the
+	// first page as well.
+	resumed, err := OpenCheckpoint(checkpoint.Path(), "job search")
+	if err != nil {
+		t.Fatalf("Error resuming checkpoint: %v", err)
+	}
+	var counts []JobQueryCount
+	client = NewScrapeClient(WithHARReplayer(newJobSearchReplayer(t, map[string]string{page(25): string(finalPage)})), WithCheckpoint(resumed))
+	jobs, err = SearchJobsOnline(client, []string{"Belgium"}, []string{"istio"}, false, WithQueryCounts(func(count JobQueryCount) {
+		counts = append(counts, count)
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error resuming, but got %v", err)
+	}
+	if len(jobs) != 16 {
+		t.Errorf("Expected 16 new jobs resuming, but got %d", len(jobs))
+	}
+	if len(counts) != 1 || counts[0].Total != 41 || counts[0].Found != 41 || counts[0].New != 41 {
+		t.Errorf("Expected a total of 41 jobs, all found and new, but got %v", counts)
+	}
+}
+
+func TestStreamJobsOnlineResumesRepeatedPages(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	basepath := filepath.Dir(filename)
+	firstPage, err := os.ReadFile(filepath.Join(basepath, "../..", "testdata", "job-search", "jobs-0.html"))
+	if err != nil {
+		t.Fatalf("Error reading jobs-0.html: %v", err)
+	}
+
+	query := mustParseQuery(t, "istio")
+	page := func(offset int) string {
+		return jobSearchURL("Belgium", query, JobFilters{}, offset)
+	}
+	checkpoint, err := OpenCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"), "job search")
+	if err != nil {
+		t.Fatalf("Error opening checkpoint: %v", err)
+	}
+
+	client := NewScrapeClient(WithHARReplayer(newJobSearchReplayer(t, map[string]string{page(0): string(firstPage)})), WithCheckpoint(checkpoint))
+	if _, err := SearchJobsOnline(client, []string{"Belgium"}, []string{"istio"}, false); err == nil {
+		t.Fatalf("Expected an error for the missing page, but got none")
+	}
+
+	// The second page repeats the resumed first one, so the search stops
+	// there instead of fetching the missing third page.
+	resumed, err := OpenCheckpoint(checkpoint.Path(), "job search")
+	if err != nil {
+		t.Fatalf("Error resuming checkpoint: %v", err)
+	}
+	client = NewScrapeClient(WithHARReplayer(newJobSearchReplayer(t, map[string]string{page(25): string(firstPage)})), WithCheckpoint(resumed))
+	jobs, err := SearchJobsOnline(client, []string{"Belgium"}, []string{"istio"}, false)
+	if err != nil {
+		t.Fatalf("Expected no error resuming, but got %v", err)
+	}
+	if len(jobs) != 0 {
+		t.Errorf("Expected no new jobs resuming, but got %d", len(jobs))
+	}
+}
@@ -23,7 +23,7 @@ func extractLikesCount(s string) (int, error) {
 }
 
 func extractCommentsCount(s string) (int, error) {
-	re := regexp.MustCompile(`(\d+,?\d*)\s*Comments`)
+	re := regexp.MustCompile(`(\d+,?\d*)\s*Comments?`)
 	match := re.FindStringSubmatch(s)
 	if len(match) < 2 {
 		return 0, fmt.Errorf("no match found")
@@ -41,3 +41,28 @@ func extractFollowersCount(s string) (int, error) {
 	numStr := strings.ReplaceAll(match[1], ",", "")
 	return strconv.Atoi(numStr)
 }
+
+// extractApplicantsCount parses captions like "133 applicants" and "Over 200
+// applicants". Captions like "Be among the first 25 applicants" tell only an
+// upper bound, so they give no count.
+func extractApplicantsCount(s string) (int, error) {
+	re := regexp.MustCompile(`^(?:Over\s+)?(\d+,?\d*)\s*applicants`)
+	match := re.FindStringSubmatch(s)
+	if len(match) < 2 {
+		return 0, fmt.Errorf("no match found")
+	}
+	numStr := strings.ReplaceAll(match[1], ",", "")
+	return strconv.Atoi(numStr)
+}
+
+// extractJobCount parses the result count of a job search like "1,234" and
+// "1,000+", the latter being a lower bound LinkedIn shows for large searches.
+func extractJobCount(s string) (int, error) {
+	re := regexp.MustCompile(`^(\d[\d,]*)\+?$`)
+	match := re.FindStringSubmatch(strings.TrimSpace(s))
+	if len(match) < 2 {
+		return 0, fmt.Errorf("no match found")
+	}
+	numStr := strings.ReplaceAll(match[1], ",", "")
+	return strconv.Atoi(numStr)
+}
@@ -52,6 +52,7 @@ func TestExtractCommentsCount(t *testing.T) {
 	}{
 		{"1,234 Comments", 1234, false},
 		{"123 Comments", 123, false},
+		{"1 Comment", 1, false},
 		{"Comments", 0, true},
 	}
 
@@ -87,3 +88,48 @@ func TestExtractFollowersCount(t *testing.T) {
 		}
 	}
 }
+
+func TestExtractApplicantsCount(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+		hasError bool
+	}{
+		{"133 applicants", 133, false},
+		{"Over 200 applicants", 200, false},
+		{"Be among the first 25 applicants", 0, true},
+	}
+
+	for _, test := range tests {
+		result, err := extractApplicantsCount(test.input)
+		if test.hasError && err == nil {
+			t.Errorf("Expected error for input %s, but got none", test.input)
+		}
+		if !test.hasError && result != test.expected {
+			t.Errorf("For input %s, expected %d, but got %d", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestExtractJobCount(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+		hasError bool
+	}{
+		{"41", 41, false},
+		{" 1,234 ", 1234, false},
+		{"1,000+", 1000, false},
+		{"", 0, true},
+	}
+
+	for _, test := range tests {
+		result, err := extractJobCount(test.input)
+		if test.hasError && err == nil {
+			t.Errorf("Expected error for input %s, but got none", test.input)
+		}
+		if !test.hasError && result != test.expected {
+			t.Errorf("For input %s, expected %d, but got %d", test.input, test.expected, result)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package linkedin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+)
+
+// streamAll fetches every URL of urls with fetch, running up to the configured
+// concurrency of client at once, and calls yield with every result in the order
+// of urls, as soon as it and all results before it are fetched. Every fetch
+// still waits for the rate limiter of the client.
+//
+// Errors are passed to yield per URL, so one bad page does not stop the others.
+// When ctx is done, the circuit breaker opens or a request budget runs out, the
+// remaining URLs are skipped and the error is returned. When yield returns
+// false, streamAll stops fetching and returns nil.
+//
+// URLs completed in the checkpoint of client are skipped, and every fetched
+// result is recorded there before it is passed to yield.
+func streamAll[T any](ctx context.Context, client *ScrapeClient, urls []string, entity string, fetch func(context.Context, string) (T, error), yield func(T, error) bool) error {
+	if client.checkpoint != nil {
+		var remaining []string
+		for _, url := range urls {
+			if _, done := client.checkpoint.completed(url); !done {
+				remaining = append(remaining, url)
+			}
+		}
+		urls = remaining
+	}
+
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type outcome struct {
+		index  int
+		result T
+		err    error
+	}
+	indexes := make(chan int)
+	outcomes := make(chan outcome)
+
+	go func() {
+		defer close(indexes)
+		for i := range urls {
+			select {
+			case indexes <- i:
+			case <-fetchCtx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < client.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result, err := fetch(fetchCtx, urls[i])
+				outcomes <- outcome{index: i, result: result, err: err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	var stopErr error
+	stopped := false
+	processed := 0
+	emit := func(o outcome) {
+		processed++
+		if stopped {
+			return
+		}
+		var ok bool
+		switch {
+		case o.err == nil:
+			if client.checkpoint != nil {
+				// A checkpoint that fails to save must not fail the run.
+				if err := client.checkpoint.complete(urls[o.index], o.result); err != nil {
+					log.Printf("failed to save checkpoint %s: %v", client.checkpoint.Path(), err)
+				}
+			}
+			ok = yield(o.result, nil)
+		case errors.Is(o.err, ErrDisallowed):
+			// Disallowed URLs are reported by client.Disallowed.
+			ok = true
+		default:
+			var zero T
+			ok = yield(zero, fmt.Errorf("error fetching %s from URL %s: %w", entity, urls[o.index], o.err))
+		}
+		if !ok {
+			stopped = true
+			cancel()
+		}
+	}
+
+	pending := make(map[int]outcome)
+	next := 0
+	for o := range outcomes {
+		if o.err != nil && fetchCtx.Err() != nil {
+			// Stopped halfway, so the URL counts as skipped.
+			continue
+		}
+		if skipRemaining(o.err, 0) != nil {
+			if stopErr == nil {
+				stopErr = o.err
+			}
+			cancel()
+			continue
+		}
+
+		pending[o.index] = o
+		for {
+			p, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			emit(p)
+		}
+	}
+
+	// URLs skipped after a stop leave gaps, so pass on what is left in order.
+	var rest []int
+	for i := range pending {
+		rest = append(rest, i)
+	}
+	sort.Ints(rest)
+	for _, i := range rest {
+		emit(pending[i])
+	}
+
+	if stopped {
+		return nil
+	}
+	if stopErr != nil {
+		return skipRemaining(stopErr, len(urls)-processed)
+	}
+	return ctx.Err()
+}
+
+// collect runs stream and returns all results it yields. Errors yielded
+// along the way are joined into the returned error, unless stream itself
+// fails.
+func collect[T any](stream func(yield func(T, error) bool) error) ([]T, error) {
+	var results []T
+	var errs []error
+	err := stream(func(result T, err error) bool {
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			results = append(results, result)
+		}
+		return true
+	})
+	if err != nil {
+		return results, err
+	}
+	if len(errs) > 0 {
+		return results, fmt.Errorf("encountered errors: %w", errors.Join(errs...))
+	}
+	return results, nil
+}
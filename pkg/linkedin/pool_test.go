@@ -0,0 +1,131 @@
+package linkedin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func fetchCompany(client *ScrapeClient) func(context.Context, string) (*Company, error) {
+	return func(ctx context.Context, url string) (*Company, error) {
+		return GetCompanyFromUrlContext(ctx, client, url, false)
+	}
+}
+
+func collectCompanies(client *ScrapeClient, urls []string) ([]*Company, error) {
+	return collect(func(yield func(*Company, error) bool) error {
+		return streamAll(context.Background(), client, urls, "company", fetchCompany(client), yield)
+	})
+}
+
+func TestStreamAllConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+				break
+			}
+		}
+
+		name := strings.TrimPrefix(r.URL.Path, "/company/")
+		if name == "missing" {
+			http.NotFound(w, r)
+			return
+		}
+		// Later pages answer faster, so they finish out of order.
+		var n int
+		fmt.Sscanf(name, "c%d", &n)
+		time.Sleep(time.Duration(10-n) * 5 * time.Millisecond)
+		fmt.Fprintf(w, `<html><body><h1 class="top-card-layout__title">%s</h1></body></html>`, name)
+	}))
+	defer server.Close()
+
+	var urls, expected []string
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("c%d", i)
+		urls = append(urls, server.URL+"/company/"+name)
+		expected = append(expected, name)
+		if i == 4 {
+			urls = append(urls, server.URL+"/company/missing")
+		}
+	}
+
+	client := NewScrapeClient(WithConcurrency(4), WithRetries(1))
+	companies, err := collectCompanies(client, urls)
+
+	if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "/company/missing") {
+		t.Errorf("Expected a not found error for the missing company, but got %v", err)
+	}
+	var names []string
+	for _, company := range companies {
+		names = append(names, company.Name)
+	}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected companies %v in order, but got %v", expected, names)
+	}
+	if maxInFlight < 2 || maxInFlight > 4 {
+		t.Errorf("Expected between 2 and 4 concurrent requests, but got %d", maxInFlight)
+	}
+}
+
+func TestStreamAllCircuitOpen(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	urls := make([]string, 10)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s/company/c%d", server.URL, i)
+	}
+
+	client := NewScrapeClient(WithConcurrency(3), WithRetries(1), WithCircuitBreaker(NewCircuitBreaker(2, 0)))
+	_, err := collectCompanies(client, urls)
+
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("Expected a CircuitOpenError, but got %v", err)
+	}
+	if openErr.Skipped == 0 || openErr.Skipped >= len(urls) {
+		t.Errorf("Expected some skipped URLs, but got %d", openErr.Skipped)
+	}
+}
+
+func TestStreamAllStop(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		fmt.Fprintf(w, `<html><body><h1 class="top-card-layout__title">%s</h1></body></html>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	urls := make([]string, 20)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s/company/c%d", server.URL, i)
+	}
+
+	client := NewScrapeClient(WithConcurrency(2))
+	var names []string
+	err := streamAll(context.Background(), client, urls, "company", fetchCompany(client), func(company *Company, err error) bool {
+		names = append(names, company.Name)
+		return len(names) < 3
+	})
+	if err != nil {
+		t.Errorf("Expected no error when stopping, but got %v", err)
+	}
+	if strings.Join(names, ",") != "/company/c0,/company/c1,/company/c2" {
+		t.Errorf("Expected the first 3 companies in order, but got %v", names)
+	}
+	if hits >= int32(len(urls)) {
+		t.Errorf("Expected fetching to stop early, but got %d requests", hits)
+	}
+}
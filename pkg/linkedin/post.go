@@ -1,29 +1,27 @@
 package linkedin
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
-	"time"
-
-	"github.com/PuerkitoBio/goquery"
-	"github.com/corpix/uarand"
 )
 
 // Post represents the structure of a LinkedIn post.
 type Post struct {
-	ActivityURN          string `json:"activityURN"            csv:"activityURN"`
-	Author               string `json:"author"                 csv:"author"`
-	AuthorLinkedInUrl    string `json:"authorLinkedInUrl"      csv:"authorLinkedInUrl"`
-	AuthorTitle          string `json:"authorTitle"            csv:"authorTitle"`
-	CommentCount         int    `json:"commmentCount"          csv:"commmentCount"`
-	CompanyFollowerCount int    `json:"companyFollowerCount"   csv:"companyFollowerCount"`
-	Freshness            string `json:"freshness"              csv:"freshness"`
-	LikesCount           int    `json:"likesCount"             csv:"likesCount"`
-	PostLink             string `json:"postLink"               csv:"postLink"`
-	PublishDate          string `json:"publishDate"            csv:"publishDate"`
-	ShareURN             string `json:"shareURN"               csv:"shareURN"`
+	ActivityURN          string       `json:"activityURN"            csv:"activityURN"`
+	Author               string       `json:"author"                 csv:"author"`
+	AuthorLinkedInUrl    string       `json:"authorLinkedInUrl"      csv:"authorLinkedInUrl"`
+	AuthorTitle          string       `json:"authorTitle"            csv:"authorTitle"`
+	CommentCount         int          `json:"commmentCount"          csv:"commmentCount"`
+	CompanyFollowerCount int          `json:"companyFollowerCount"   csv:"companyFollowerCount"`
+	Freshness            string       `json:"freshness"              csv:"freshness"`
+	LikesCount           int          `json:"likesCount"             csv:"likesCount"`
+	PostLink             string       `json:"postLink"               csv:"postLink"`
+	PublishDate          string       `json:"publishDate"            csv:"publishDate"`
+	ShareURN             string       `json:"shareURN"               csv:"shareURN"`
+	Sources              FieldSources `json:"sources,omitempty"      csv:"-"`
 }
 
 func (p *Post) CsvContent() string {
@@ -57,120 +55,116 @@ func (ps Posts) Get(i int) Serializable {
 	return Serializable(ps[i])
 }
 
-func SearchPostsOnline(keywords []string, interval time.Duration, debug bool) (Posts, error) {
-	var posts []*Post
-	urls, err := GoogleGetLinkedInPostURLs(keywords, interval, debug)
-	if err != nil {
-		return nil, fmt.Errorf("error fetching LinkedIn post URLs: %v", err)
-	}
+func SearchPostsOnline(client *ScrapeClient, keywords []string, debug bool) (Posts, error) {
+	return SearchPostsOnlineContext(context.Background(), client, keywords, debug)
+}
 
-	var errs []string
-	for _, url := range urls {
-		post, err := GetPostFromUrl(url, debug)
-		if err != nil {
-			errs = append(errs, fmt.Sprintf("error fetching post from URL %s: %v", url, err))
-			continue
-		}
-		posts = append(posts, post)
-	}
+// SearchPostsOnlineContext is like SearchPostsOnline, but stops fetching when
+// ctx is done and returns the posts fetched so far along with ctx.Err().
+func SearchPostsOnlineContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool) (Posts, error) {
+	return collect(func(yield func(*Post, error) bool) error {
+		return StreamPostsOnlineContext(ctx, client, keywords, debug, yield)
+	})
+}
 
-	if len(errs) > 0 {
-		return posts, fmt.Errorf("encountered errors: %s", strings.Join(errs, "; "))
+func StreamPostsOnline(client *ScrapeClient, keywords []string, debug bool, yield func(*Post, error) bool) error {
+	return StreamPostsOnlineContext(context.Background(), client, keywords, debug, yield)
+}
+
+// StreamPostsOnlineContext is like SearchPostsOnlineContext, but passes every
+// post to yield as soon as it is fetched, in search result order, instead of
+// returning them all at the end. Errors fetching a single post are passed to
+// yield as well. Returning false from yield stops the search.
+func StreamPostsOnlineContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool, yield func(*Post, error) bool) error {
+	urls, err := searchLinkedInURLs(ctx, client, linkedInPostSite, keywords, debug)
+	if err != nil {
+		return fmt.Errorf("error fetching LinkedIn post URLs: %w", err)
 	}
 
-	return posts, nil
+	return streamAll(ctx, client, urls, "post", func(ctx context.Context, url string) (*Post, error) {
+		return GetPostFromUrlContext(ctx, client, url, debug)
+	}, yield)
 }
 
-func GetPostFromUrl(url string, debug bool) (*Post, error) {
+func GetPostFromUrl(client *ScrapeClient, url string, debug bool) (*Post, error) {
+	return GetPostFromUrlContext(context.Background(), client, url, debug)
+}
+
+// GetPostFromUrlContext is like GetPostFromUrl, but aborts the request when ctx
+// is done.
+func GetPostFromUrlContext(ctx context.Context, client *ScrapeClient, url string, debug bool) (*Post, error) {
 	if debug {
 		fmt.Printf("going to fetch post from url %v", url)
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := newRequest(ctx, url)
 	if err != nil {
 		return &Post{}, err
 	}
-	req.Header.Set("Accept-Encoding", "identity")
-	req.Header.Set("User-Agent", uarand.GetRandom())
 
-	post, err := getPostFromRequest(req, debug)
+	return getPostFromRequest(client, req, debug)
+}
+
+func getPostFromRequest(client *ScrapeClient, req *http.Request, debug bool) (*Post, error) {
+	doc, err := client.fetchDocument(req)
 	if err != nil {
-		if httpErr, ok := err.(*HTTPError); ok && httpErr.StatusCode == http.StatusTooManyRequests {
-			return &Post{}, err
-		}
 		return &Post{}, err
 	}
-	return post, nil
-}
 
-func getPostFromRequest(req *http.Request, debug bool) (*Post, error) {
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return &Post{}, fmt.Errorf("failed to fetch LinkedIn post: %w", err)
+	data := parseStructuredData(doc)
+	posting := data.object("SocialMediaPosting")
+	sources := make(FieldSources)
+
+	// The fields of the structured data do not depend on the article markup,
+	// so a page without it still gives them.
+	s := doc.Find("article").First()
+	header := s.Find("div[data-test-id=main-feed-activity-card__entity-lockup]")
+	footer := s.Find(".main-feed-activity-card__social-actions")
+
+	activityURN := pick(sources, "activityURN", fromCSS(strings.TrimSpace(s.AttrOr("data-activity-urn", ""))))
+	author := pick(sources, "author", fromJSONLD(posting.str("author", "name")), fromCSS(strings.TrimSpace(header.Find(".leading-open").Text())))
+	authorLinkedInUrl := pick(sources, "authorLinkedInUrl", fromJSONLD(cleanURL(posting.str("author", "url"))), fromCSS(cleanURL(strings.TrimSpace(header.Find(".leading-open").AttrOr("href", "")))))
+	cssCommentCount, _ := extractCommentsCount(strings.TrimSpace(footer.Find("span[data-test-id=social-actions__comments]").Text()))
+	commmentCount := pick(sources, "commmentCount", fromCSS(cssCommentCount))
+	freshness := pick(sources, "freshness", fromCSS(strings.Split(strings.TrimSpace(header.Find("div span time").Text()), "\n")[0]))
+	cssLikesCount, _ := extractLikesCount(strings.TrimSpace(footer.Find("span[data-test-id=social-actions__reaction-count]").Text()))
+	likesCount := pick(sources, "likesCount", fromCSS(cssLikesCount))
+	postLink := pick(sources, "postLink", fromJSONLD(cleanURL(posting.str("@id"))), fromOpenGraph(cleanURL(data.og("url"))), fromCSS(cleanURL(doc.Find("head link").AttrOr("href", ""))))
+	publishDate := pick(sources, "publishDate", fromJSONLD(posting.date("datePublished")))
+	shareURN := pick(sources, "shareURN", fromCSS(strings.TrimSpace(s.AttrOr("data-attributed-urn", ""))))
+
+	var cssCompanyFollowerCount int
+	var cssAuthorTitle string
+	if strings.Contains(authorLinkedInUrl, "linkedin.com/company") {
+		cssCompanyFollowerCount, _ = extractFollowersCount(strings.TrimSpace(header.Find("div p").Text()))
+	} else {
+		cssAuthorTitle = strings.TrimSpace(header.Find("div p").Text())
 	}
-	defer resp.Body.Close()
-
-	// Check for non-2xx status codes
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return &Post{}, &HTTPError{
-			StatusCode: resp.StatusCode,
-			Message:    fmt.Sprintf("received non-2xx response: %d %s", resp.StatusCode, resp.Status),
-		}
+	authorTitle := pick(sources, "authorTitle", fromCSS(cssAuthorTitle))
+	companyFollowerCount := pick(sources, "companyFollowerCount", fromCSS(cssCompanyFollowerCount))
+
+	post := Post{
+		ActivityURN:          activityURN,
+		Author:               author,
+		AuthorLinkedInUrl:    authorLinkedInUrl,
+		AuthorTitle:          authorTitle,
+		CommentCount:         commmentCount,
+		CompanyFollowerCount: companyFollowerCount,
+		Freshness:            freshness,
+		LikesCount:           likesCount,
+		PostLink:             postLink,
+		PublishDate:          publishDate,
+		ShareURN:             shareURN,
+		Sources:              sources,
 	}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		return &Post{}, fmt.Errorf("failed to parse HTML: %w", err)
+	// Print the post for testing
+	if debug {
+		log.Printf("Post: %+v", post)
 	}
 
-	var post Post
-	stopIteration := false
-	doc.Find("article").Each(func(i int, s *goquery.Selection) {
-		if stopIteration {
-			return
-		}
-		header := s.Find("div[data-test-id=main-feed-activity-card__entity-lockup]")
-		footer := s.Find(".main-feed-activity-card__social-actions")
-
-		activityURN := strings.TrimSpace(s.AttrOr("data-activity-urn", ""))
-		author := strings.TrimSpace(header.Find(".leading-open").Text())
-		authorLinkedInUrl := cleanURL(strings.TrimSpace(header.Find(".leading-open").AttrOr("href", "")))
-		commmentCount, _ := extractCommentsCount(strings.TrimSpace(footer.Find("span[data-test-id=social-actions__comments]").Text()))
-		freshness := strings.Split(strings.TrimSpace(header.Find("div span time").Text()), "\n")[0]
-		likesCount, _ := extractLikesCount(strings.TrimSpace(footer.Find("span[data-test-id=social-actions__reaction-count]").Text()))
-		postLink := cleanURL(doc.Find("head link").AttrOr("href", ""))
-		shareURN := strings.TrimSpace(s.AttrOr("data-attributed-urn", ""))
-
-		var companyFollowerCount int
-		var authorTitle string
-		if strings.Contains(authorLinkedInUrl, "linkedin.com/company") {
-			authorTitle = ""
-			companyFollowerCount, _ = extractFollowersCount(strings.TrimSpace(header.Find("div p").Text()))
-		} else {
-			authorTitle = strings.TrimSpace(header.Find("div p").Text())
-			companyFollowerCount = 0
-		}
-
-		post = Post{
-			ActivityURN:          activityURN,
-			Author:               author,
-			AuthorLinkedInUrl:    authorLinkedInUrl,
-			AuthorTitle:          authorTitle,
-			CommentCount:         commmentCount,
-			CompanyFollowerCount: companyFollowerCount,
-			Freshness:            freshness,
-			LikesCount:           likesCount,
-			PostLink:             postLink,
-			ShareURN:             shareURN,
-		}
-
-		// Print the post for testing
-		if debug {
-			log.Printf("Post: %+v", post)
-		}
-
-		stopIteration = true
-	})
+	if post.Author == "" {
+		return &post, &ParseError{URL: req.URL.String(), Entity: "post"}
+	}
 	return &post, nil
 }
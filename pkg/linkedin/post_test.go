@@ -232,7 +232,7 @@ func TestGetPostFromRequest(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Error creating HTTP request: %v", err)
 			}
-			post, err := getPostFromRequest(req, false)
+			post, err := getPostFromRequest(NewScrapeClient(), req, false)
 			if err != nil {
 				t.Fatalf("Error in getPostFromRequest for file %s: %s", tt.fileName, err)
 			}
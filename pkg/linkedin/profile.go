@@ -0,0 +1,148 @@
+package linkedin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// HeaderProfile is a named set of request headers that a real browser sends
+// together, such as a matching User-Agent, Accept, Accept-Language and
+// sec-ch-ua. Headers are set in the listed order, but Go's HTTP stack decides
+// the order they are written in.
+type HeaderProfile struct {
+	Name    string          `json:"name"`
+	Headers []ProfileHeader `json:"headers"`
+}
+
+// ProfileHeader is a single header of a HeaderProfile.
+type ProfileHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// UserAgent returns the User-Agent header of the profile, or an empty string
+// when it has none.
+func (p *HeaderProfile) UserAgent() string {
+	for _, h := range p.Headers {
+		if strings.EqualFold(h.Name, "User-Agent") {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// apply sets the headers of the profile on header, except the ones header
+// sets already.
+func (p *HeaderProfile) apply(header http.Header) {
+	for _, h := range p.Headers {
+		if header.Get(h.Name) == "" {
+			header.Set(h.Name, h.Value)
+		}
+	}
+}
+
+// DefaultHeaderProfiles are the built-in header profiles, by name.
+var DefaultHeaderProfiles = map[string]*HeaderProfile{
+	"chrome": {
+		Name: "chrome",
+		Headers: []ProfileHeader{
+			{"sec-ch-ua", `"Google Chrome";v="117", "Not;A=Brand";v="8", "Chromium";v="117"`},
+			{"sec-ch-ua-mobile", "?0"},
+			{"sec-ch-ua-platform", `"macOS"`},
+			{"Upgrade-Insecure-Requests", "1"},
+			{"User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/117.0.0.0 Safari/537.36"},
+			{"Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7"},
+			{"Sec-Fetch-Site", "none"},
+			{"Sec-Fetch-Mode", "navigate"},
+			{"Sec-Fetch-User", "?1"},
+			{"Sec-Fetch-Dest", "document"},
+			{"Accept-Encoding", "gzip, deflate, br"},
+			{"Accept-Language", "en-US,en;q=0.9"},
+		},
+	},
+	"firefox": {
+		Name: "firefox",
+		Headers: []ProfileHeader{
+			{"User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:118.0) Gecko/20100101 Firefox/118.0"},
+			{"Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8"},
+			{"Accept-Language", "en-US,en;q=0.5"},
+			{"Accept-Encoding", "gzip, deflate, br"},
+			{"Upgrade-Insecure-Requests", "1"},
+			{"Sec-Fetch-Dest", "document"},
+			{"Sec-Fetch-Mode", "navigate"},
+			{"Sec-Fetch-Site", "none"},
+			{"Sec-Fetch-User", "?1"},
+		},
+	},
+	"safari-mobile": {
+		Name: "safari-mobile",
+		Headers: []ProfileHeader{
+			{"Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"},
+			{"Sec-Fetch-Site", "none"},
+			{"Accept-Encoding", "gzip, deflate, br"},
+			{"Sec-Fetch-Mode", "navigate"},
+			{"User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1"},
+			{"Accept-Language", "en-US,en;q=0.9"},
+			{"Sec-Fetch-Dest", "document"},
+		},
+	},
+}
+
+// HeaderProfileNames returns the names of the built-in header profiles.
+func HeaderProfileNames() []string {
+	names := make([]string, 0, len(DefaultHeaderProfiles))
+	for name := range DefaultHeaderProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// randomHeaderProfile picks one of the built-in header profiles.
+func randomHeaderProfile() *HeaderProfile {
+	names := HeaderProfileNames()
+	return DefaultHeaderProfiles[names[rand.Intn(len(names))]]
+}
+
+// LoadHeaderProfiles reads header profiles from a JSON file holding either a
+// single profile or a list of them.
+func LoadHeaderProfiles(path string) ([]*HeaderProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open profile file: %w", err)
+	}
+
+	var profiles []*HeaderProfile
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var profile HeaderProfile
+		if err := json.Unmarshal(trimmed, &profile); err != nil {
+			return nil, fmt.Errorf("failed to read profile file: %w", err)
+		}
+		profiles = append(profiles, &profile)
+	} else if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to read profile file: %w", err)
+	}
+
+	for _, profile := range profiles {
+		if profile.Name == "" {
+			return nil, fmt.Errorf("failed to read profile file %s: profile without name", path)
+		}
+	}
+	return profiles, nil
+}
+
+// ParseHeader parses a header in "Name: value" form.
+func ParseHeader(s string) (string, string, error) {
+	name, value, found := strings.Cut(s, ":")
+	name = strings.TrimSpace(name)
+	if !found || name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", fmt.Errorf("invalid header %q: expected \"Name: value\"", s)
+	}
+	return name, strings.TrimSpace(value), nil
+}
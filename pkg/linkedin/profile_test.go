@@ -0,0 +1,113 @@
+package linkedin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedName  string
+		expectedValue string
+		expectError   bool
+	}{
+		{"Accept-Language: nl-BE,nl;q=0.9", "Accept-Language", "nl-BE,nl;q=0.9", false},
+		{"X-Empty:", "X-Empty", "", false},
+		{"Referer: https://www.google.com/", "Referer", "https://www.google.com/", false},
+		{"no colon", "", "", true},
+		{": value", "", "", true},
+		{"Bad Name: value", "", "", true},
+	}
+
+	for _, test := range tests {
+		name, value, err := ParseHeader(test.input)
+		if (err != nil) != test.expectError {
+			t.Errorf("For input %q, expected error %v, but got %v", test.input, test.expectError, err)
+			continue
+		}
+		if name != test.expectedName || value != test.expectedValue {
+			t.Errorf("For input %q, expected %q: %q, but got %q: %q", test.input, test.expectedName, test.expectedValue, name, value)
+		}
+	}
+}
+
+func TestLoadHeaderProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{"single", `{"name":"edge","headers":[{"name":"User-Agent","value":"Edge"}]}`, []string{"edge"}},
+		{"list", `[{"name":"a","headers":[]},{"name":"b","headers":[]}]`, []string{"a", "b"}},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "profiles.json")
+		os.WriteFile(path, []byte(test.content), 0644)
+		profiles, err := LoadHeaderProfiles(path)
+		if err != nil {
+			t.Errorf("For %s, unexpected error: %v", test.name, err)
+			continue
+		}
+		if len(profiles) != len(test.expected) {
+			t.Errorf("For %s, expected %d profiles, but got %d", test.name, len(test.expected), len(profiles))
+			continue
+		}
+		for i, profile := range profiles {
+			if profile.Name != test.expected[i] {
+				t.Errorf("For %s, expected profile %s, but got %s", test.name, test.expected[i], profile.Name)
+			}
+		}
+	}
+}
+
+func TestScrapeClientHeaderProfile(t *testing.T) {
+	var received []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Clone())
+	}))
+	defer server.Close()
+
+	chrome := DefaultHeaderProfiles["chrome"]
+	client := NewScrapeClient(
+		WithHeaderProfile(chrome),
+		WithHeaders(http.Header{"Accept-Language": {"nl-BE"}, "X-Test": {"1"}}),
+	)
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(context.Background(), server.URL)
+		if err != nil {
+			t.Fatalf("Error sending request: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	for _, header := range received {
+		if header.Get("User-Agent") != chrome.UserAgent() {
+			t.Errorf("Expected the chrome User-Agent, but got %s", header.Get("User-Agent"))
+		}
+		if header.Get("Sec-Ch-Ua") == "" {
+			t.Errorf("Expected the sec-ch-ua header of the chrome profile")
+		}
+		if header.Get("Accept-Language") != "nl-BE" || header.Get("X-Test") != "1" {
+			t.Errorf("Expected the header overrides, but got %v", header)
+		}
+	}
+}
+
+func TestScrapeClientStableProfile(t *testing.T) {
+	client := NewScrapeClient()
+	profile := client.Profile()
+	if profile == nil || DefaultHeaderProfiles[profile.Name] != profile {
+		t.Fatalf("Expected a built-in profile, but got %v", profile)
+	}
+	for i := 0; i < 10; i++ {
+		if ua := client.randomUserAgent(); ua != profile.UserAgent() {
+			t.Errorf("Expected User-Agent %s for the whole session, but got %s", profile.UserAgent(), ua)
+		}
+	}
+}
@@ -1,27 +1,25 @@
 package linkedin
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
-	"time"
-
-	"github.com/PuerkitoBio/goquery"
-	"github.com/corpix/uarand"
 )
 
 // Pulse represents the structure of a LinkedIn pulse.
 type Pulse struct {
-	Author               string `json:"author"                csv:"author"`
-	AuthorLinkedInUrl    string `json:"authorLinkedInUrl"     csv:"authorLinkedInUrl"`
-	AuthorTitle          string `json:"authorTitle"           csv:"authorTitle"`
-	CommentCount         int    `json:"commmentCount"         csv:"commmentCount"`
-	AuthorFollowingCount int    `json:"authorFollowingCount"  csv:"authorFollowingCount"`
-	LikesCount           int    `json:"likesCount"            csv:"likesCount"`
-	PublishDate          string `json:"publishDate"           csv:"publishDate"`
-	PulseLink            string `json:"pulseLink"             csv:"pulseLink"`
-	Title                string `json:"title"                 csv:"title"`
+	Author               string       `json:"author"                csv:"author"`
+	AuthorLinkedInUrl    string       `json:"authorLinkedInUrl"     csv:"authorLinkedInUrl"`
+	AuthorTitle          string       `json:"authorTitle"           csv:"authorTitle"`
+	CommentCount         int          `json:"commmentCount"         csv:"commmentCount"`
+	AuthorFollowingCount int          `json:"authorFollowingCount"  csv:"authorFollowingCount"`
+	LikesCount           int          `json:"likesCount"            csv:"likesCount"`
+	PublishDate          string       `json:"publishDate"           csv:"publishDate"`
+	PulseLink            string       `json:"pulseLink"             csv:"pulseLink"`
+	Title                string       `json:"title"                 csv:"title"`
+	Sources              FieldSources `json:"sources,omitempty"     csv:"-"`
 }
 
 func (p *Pulse) CsvContent() string {
@@ -55,87 +53,87 @@ func (ps Pulses) Get(i int) Serializable {
 	return Serializable(ps[i])
 }
 
-func SearchPulsesOnline(keywords []string, interval time.Duration, debug bool) (Pulses, error) {
-	var pulses []*Pulse
-	urls, err := GoogleGetLinkedInPulseURLs(keywords, interval, debug)
-	if err != nil {
-		return nil, fmt.Errorf("error fetching LinkedIn pulse URLs: %v", err)
-	}
+func SearchPulsesOnline(client *ScrapeClient, keywords []string, debug bool) (Pulses, error) {
+	return SearchPulsesOnlineContext(context.Background(), client, keywords, debug)
+}
 
-	var errs []string
-	for _, url := range urls {
-		pulse, err := GetPulseFromUrl(url, debug)
-		if err != nil {
-			errs = append(errs, fmt.Sprintf("error fetching pulse from URL %s: %v", url, err))
-			continue
-		}
-		pulses = append(pulses, pulse)
-	}
+// SearchPulsesOnlineContext is like SearchPulsesOnline, but stops fetching when
+// ctx is done and returns the pulses fetched so far along with ctx.Err().
+func SearchPulsesOnlineContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool) (Pulses, error) {
+	return collect(func(yield func(*Pulse, error) bool) error {
+		return StreamPulsesOnlineContext(ctx, client, keywords, debug, yield)
+	})
+}
 
-	if len(errs) > 0 {
-		return pulses, fmt.Errorf("encountered errors: %s", strings.Join(errs, "; "))
+func StreamPulsesOnline(client *ScrapeClient, keywords []string, debug bool, yield func(*Pulse, error) bool) error {
+	return StreamPulsesOnlineContext(context.Background(), client, keywords, debug, yield)
+}
+
+// StreamPulsesOnlineContext is like SearchPulsesOnlineContext, but passes every
+// pulse to yield as soon as it is fetched, in search result order, instead of
+// returning them all at the end. Errors fetching a single pulse are passed to
+// yield as well. Returning false from yield stops the search.
+func StreamPulsesOnlineContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool, yield func(*Pulse, error) bool) error {
+	urls, err := searchLinkedInURLs(ctx, client, linkedInPulseSite, keywords, debug)
+	if err != nil {
+		return fmt.Errorf("error fetching LinkedIn pulse URLs: %w", err)
 	}
 
-	return pulses, nil
+	return streamAll(ctx, client, urls, "pulse", func(ctx context.Context, url string) (*Pulse, error) {
+		return GetPulseFromUrlContext(ctx, client, url, debug)
+	}, yield)
+}
+
+func GetPulseFromUrl(client *ScrapeClient, url string, debug bool) (*Pulse, error) {
+	return GetPulseFromUrlContext(context.Background(), client, url, debug)
 }
 
-func GetPulseFromUrl(url string, debug bool) (*Pulse, error) {
+// GetPulseFromUrlContext is like GetPulseFromUrl, but aborts the request when
+// ctx is done.
+func GetPulseFromUrlContext(ctx context.Context, client *ScrapeClient, url string, debug bool) (*Pulse, error) {
 	if debug {
 		fmt.Printf("going to fetch pulse from url %v", url)
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := newRequest(ctx, url)
 	if err != nil {
 		return &Pulse{}, err
 	}
-	req.Header.Set("Accept-Encoding", "identity")
-	req.Header.Set("User-Agent", uarand.GetRandom())
 
-	pulse, err := getPulseFromRequest(req, debug)
-	if err != nil {
-		if httpErr, ok := err.(*HTTPError); ok && httpErr.StatusCode == http.StatusTooManyRequests {
-			return &Pulse{}, err
-		}
-		return &Pulse{}, err
-	}
-	return pulse, nil
+	return getPulseFromRequest(client, req, debug)
 }
 
-func getPulseFromRequest(req *http.Request, debug bool) (*Pulse, error) {
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return &Pulse{}, fmt.Errorf("failed to fetch LinkedIn pulse: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check for non-2xx status codes
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return &Pulse{}, &HTTPError{
-			StatusCode: resp.StatusCode,
-			Message:    fmt.Sprintf("received non-2xx response: %d %s", resp.StatusCode, resp.Status),
-		}
-	}
-
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+func getPulseFromRequest(client *ScrapeClient, req *http.Request, debug bool) (*Pulse, error) {
+	doc, err := client.fetchDocument(req)
 	if err != nil {
-		return &Pulse{}, fmt.Errorf("failed to parse HTML: %w", err)
+		return &Pulse{}, err
 	}
 
 	var pulse Pulse
+	data := parseStructuredData(doc)
+	article := data.object("Article")
+	sources := make(FieldSources)
 	header := doc.Find(".base-main-card--link")
 	footer := doc.Find(".main-publisher-card")
 	social := doc.Find(".x-social-activity")
 
-	author := strings.TrimSpace(header.Find(".base-card__full-link").Text())
-	authorLinkedInUrl := cleanURL(strings.TrimSpace(header.Find(".base-card__full-link").AttrOr("href", "")))
-	authorTitle := strings.TrimSpace(header.Find(".base-main-card__subtitle").Text())
-	commmentCount, _ := extractCommentsCount(strings.TrimSpace(social.Find("a[data-test-id='social-actions__comments']").Text()))
-	authorFollowingCount, _ := extractFollowersCount(strings.TrimSpace(footer.Find(".base-main-card__subtitle").Text()))
-	likesCount, _ := extractLikesCount(strings.TrimSpace(social.Find("span[data-test-id='social-actions__reaction-count']").Text()))
-	publishDate := strings.TrimSpace(header.Find(".base-main-card__metadata").Text())
-	pulseLink := cleanURL(doc.Find("head link").AttrOr("href", ""))
-	title := strings.TrimSpace(doc.Find(".pulse-title").Text())
+	author := pick(sources, "author", fromJSONLD(article.str("author", "name")), fromCSS(strings.TrimSpace(header.Find(".base-card__full-link").Text())))
+	authorLinkedInUrl := pick(sources, "authorLinkedInUrl", fromJSONLD(cleanURL(article.str("author", "url"))), fromCSS(cleanURL(strings.TrimSpace(header.Find(".base-card__full-link").AttrOr("href", "")))))
+	authorTitle := pick(sources, "authorTitle", fromCSS(strings.TrimSpace(header.Find(".base-main-card__subtitle").Text())))
+	cssCommentCount, _ := extractCommentsCount(strings.TrimSpace(social.Find("a[data-test-id='social-actions__comments']").Text()))
+	commmentCount := pick(sources, "commmentCount", fromJSONLD(article.num("commentCount")), fromCSS(cssCommentCount))
+	cssAuthorFollowingCount, _ := extractFollowersCount(strings.TrimSpace(footer.Find(".base-main-card__subtitle").Text()))
+	authorFollowingCount := pick(sources, "authorFollowingCount", fromCSS(cssAuthorFollowingCount))
+	cssLikesCount, _ := extractLikesCount(strings.TrimSpace(social.Find("span[data-test-id='social-actions__reaction-count']").Text()))
+	likesCount := pick(sources, "likesCount", fromJSONLD(article.interactions("LikeAction")), fromCSS(cssLikesCount))
+	// Publish dates keep the format of the markup, whatever their source.
+	var ldPublishDate string
+	if published, ok := article.time("datePublished"); ok {
+		ldPublishDate = "Published " + published.Format("Jan 2, 2006")
+	}
+	publishDate := pick(sources, "publishDate", fromJSONLD(ldPublishDate), fromCSS(strings.TrimSpace(header.Find(".base-main-card__metadata").Text())))
+	pulseLink := pick(sources, "pulseLink", fromJSONLD(cleanURL(article.str("url"))), fromOpenGraph(cleanURL(data.og("url"))), fromCSS(cleanURL(doc.Find("head link").AttrOr("href", ""))))
+	title := pick(sources, "title", fromJSONLD(article.str("name")), fromOpenGraph(data.og("title")), fromCSS(strings.TrimSpace(doc.Find(".pulse-title").Text())))
 
 	pulse = Pulse{
 		Author:               author,
@@ -147,6 +145,7 @@ func getPulseFromRequest(req *http.Request, debug bool) (*Pulse, error) {
 		PublishDate:          publishDate,
 		PulseLink:            pulseLink,
 		Title:                title,
+		Sources:              sources,
 	}
 
 	// Print the pulse for testing
@@ -154,5 +153,8 @@ func getPulseFromRequest(req *http.Request, debug bool) (*Pulse, error) {
 		log.Printf("Pulse: %+v", pulse)
 	}
 
+	if pulse.Title == "" {
+		return &pulse, &ParseError{URL: req.URL.String(), Entity: "pulse"}
+	}
 	return &pulse, nil
 }
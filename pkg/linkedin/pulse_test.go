@@ -169,7 +169,7 @@ func TestGetPulseFromRequest(t *testing.T) {
 			"Manisha S.",
 			"https://in.linkedin.com/in/manisha23",
 			"Freelance",
-			0,
+			1, // The page shows "1 Comment", which used to be missed as 0
 			0,
 			2,
 			"Published Mar 12, 2023",
@@ -219,7 +219,7 @@ func TestGetPulseFromRequest(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Error creating HTTP request: %v", err)
 			}
-			pulse, err := getPulseFromRequest(req, false)
+			pulse, err := getPulseFromRequest(NewScrapeClient(), req, false)
 			if err != nil {
 				t.Fatalf("Error in getPulseFromRequest for file %s: %s", tt.fileName, err)
 			}
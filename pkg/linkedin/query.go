@@ -0,0 +1,484 @@
+package linkedin
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Recency restricts a search to results posted within a recent period.
+type Recency string
+
+const (
+	RecencyAny   Recency = ""
+	RecencyDay   Recency = "day"
+	RecencyWeek  Recency = "week"
+	RecencyMonth Recency = "month"
+	RecencyYear  Recency = "year"
+)
+
+// Query is a parsed search query. The query language supports:
+//
+//	word               a word
+//	"two words"        a phrase
+//	a b, a AND b       both a and b; commas separate terms like spaces
+//	a OR b             either a or b
+//	-a, NOT a          not a
+//	(a OR b) c         grouping
+//	intitle:word       a word or phrase in the title of the page
+//	site:example.com   pages of a site
+//	posted:week        posted within the last day, week, month or year
+//
+// Operators are only recognized in upper case. A query compiles into the
+// keywords syntax of the LinkedIn job search with Keywords, and into search
+// engine operators with SearchTerms and Posted.
+type Query struct {
+	root   *queryNode
+	posted Recency
+}
+
+type queryOp int
+
+const (
+	opTerm queryOp = iota
+	opAnd
+	opOr
+	opNot
+
+	// opTop is the parent of the root node when rendering.
+	opTop queryOp = -1
+)
+
+type queryNode struct {
+	op       queryOp
+	text     string
+	phrase   bool
+	field    string
+	explicit bool // AND was spelled out between the children
+	children []*queryNode
+}
+
+// queryFields are the field prefixes of the query language.
+var queryFields = map[string]bool{
+	"intitle": true,
+	"posted":  true,
+	"site":    true,
+}
+
+// ParseQuery parses a query in the query language described at Query.
+func ParseQuery(s string) (*Query, error) {
+	tokens, err := lexQuery(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %q: %w", s, err)
+	}
+	if len(tokens) == 0 {
+		return &Query{}, nil
+	}
+	p := &queryParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %s", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %q: %w", s, err)
+	}
+
+	q := &Query{}
+	if q.root, err = q.extractPosted(root, true); err != nil {
+		return nil, fmt.Errorf("invalid query %q: %w", s, err)
+	}
+	return q, nil
+}
+
+// ParseKeywords parses every keyword as a query and combines them, so that
+// results have to match all of them.
+func ParseKeywords(keywords []string) (*Query, error) {
+	combined := &Query{}
+	var children []*queryNode
+	for _, keyword := range keywords {
+		q, err := ParseQuery(keyword)
+		if err != nil {
+			return nil, err
+		}
+		if q.posted != RecencyAny {
+			combined.posted = q.posted
+		}
+		if q.root != nil {
+			children = append(children, q.root)
+		}
+	}
+	combined.root = newAndNode(children, false)
+	return combined, nil
+}
+
+// Keywords returns the query in the keywords syntax of the LinkedIn job
+// search. Site restrictions are dropped, and intitle: terms match anywhere.
+func (q *Query) Keywords() string {
+	return renderKeywords(q.root, opTop)
+}
+
+// SearchTerms returns the query in the operator syntax search engines share.
+// The recency is not part of it, as every engine has its own parameter for
+// it.
+func (q *Query) SearchTerms() string {
+	return renderSearchTerms(q.root, opTop)
+}
+
+// Posted returns the recency of the posted: term, if any.
+func (q *Query) Posted() Recency {
+	return q.posted
+}
+
+// String returns the query in the query language.
+func (q *Query) String() string {
+	s := q.SearchTerms()
+	if q.posted != RecencyAny {
+		s = strings.TrimSpace(s + " posted:" + string(q.posted))
+	}
+	return s
+}
+
+// withSite returns the query restricted to the pages under site.
+func (q *Query) withSite(site string) *Query {
+	term := &queryNode{op: opTerm, field: "site", text: site}
+	children := []*queryNode{term}
+	if q.root != nil {
+		children = append(children, q.root)
+	}
+	return &Query{root: newAndNode(children, false), posted: q.posted}
+}
+
+// extractPosted removes the posted: terms from node. They only make sense as
+// a restriction of the whole query, so they must not be part of an OR or NOT.
+func (q *Query) extractPosted(node *queryNode, topLevel bool) (*queryNode, error) {
+	if node == nil {
+		return nil, nil
+	}
+	switch node.op {
+	case opTerm:
+		if node.field != "posted" {
+			return node, nil
+		}
+		if !topLevel {
+			return nil, fmt.Errorf("posted:%s can not be part of OR or NOT", node.text)
+		}
+		recency := Recency(strings.ToLower(node.text))
+		switch recency {
+		case RecencyDay, RecencyWeek, RecencyMonth, RecencyYear:
+		default:
+			return nil, fmt.Errorf("posted should be day, week, month or year, got %s", node.text)
+		}
+		q.posted = recency
+		return nil, nil
+	case opAnd:
+		var children []*queryNode
+		for _, child := range node.children {
+			child, err := q.extractPosted(child, topLevel)
+			if err != nil {
+				return nil, err
+			}
+			if child != nil {
+				children = append(children, child)
+			}
+		}
+		return newAndNode(children, node.explicit), nil
+	default:
+		for _, child := range node.children {
+			if _, err := q.extractPosted(child, false); err != nil {
+				return nil, err
+			}
+		}
+		return node, nil
+	}
+}
+
+func newAndNode(children []*queryNode, explicit bool) *queryNode {
+	switch len(children) {
+	case 0:
+		return nil
+	case 1:
+		return children[0]
+	}
+	return &queryNode{op: opAnd, explicit: explicit, children: children}
+}
+
+func renderKeywords(node *queryNode, parent queryOp) string {
+	if node == nil {
+		return ""
+	}
+	switch node.op {
+	case opTerm:
+		if node.field == "site" {
+			return ""
+		}
+		return quoteTerm(node)
+	case opNot:
+		child := renderKeywords(node.children[0], opNot)
+		if child == "" {
+			return ""
+		}
+		return "NOT " + child
+	}
+
+	sep := " OR "
+	if node.op == opAnd {
+		sep = " "
+		if node.explicit {
+			sep = " AND "
+		}
+	}
+	var parts []string
+	for _, child := range node.children {
+		if part := renderKeywords(child, node.op); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	s := strings.Join(parts, sep)
+	if len(parts) > 1 && parent != opTop && parent != node.op {
+		s = "(" + s + ")"
+	}
+	return s
+}
+
+func renderSearchTerms(node *queryNode, parent queryOp) string {
+	if node == nil {
+		return ""
+	}
+	switch node.op {
+	case opTerm:
+		if node.field != "" {
+			return node.field + ":" + quoteTerm(node)
+		}
+		return quoteTerm(node)
+	case opNot:
+		child := node.children[0]
+		switch child.op {
+		case opNot:
+			return renderSearchTerms(child.children[0], parent)
+		case opOr, opAnd:
+			// Search engines do not negate groups, so negate every child
+			// instead, which turns OR into AND and the other way around.
+			negated := make([]*queryNode, len(child.children))
+			for i, c := range child.children {
+				negated[i] = &queryNode{op: opNot, children: []*queryNode{c}}
+			}
+			if child.op == opAnd {
+				return renderSearchTerms(&queryNode{op: opOr, children: negated}, parent)
+			}
+			return renderSearchTerms(newAndNode(negated, false), parent)
+		}
+		return "-" + renderSearchTerms(child, opNot)
+	}
+
+	sep := " "
+	if node.op == opOr {
+		sep = " OR "
+	}
+	parts := make([]string, len(node.children))
+	for i, child := range node.children {
+		parts[i] = renderSearchTerms(child, node.op)
+	}
+	// Search engines bind OR tighter than AND, so every nested group needs
+	// parentheses.
+	s := strings.Join(parts, sep)
+	if parent != opTop && parent != node.op {
+		s = "(" + s + ")"
+	}
+	return s
+}
+
+func quoteTerm(node *queryNode) string {
+	if node.phrase {
+		return `"` + node.text + `"`
+	}
+	return node.text
+}
+
+type tokenKind int
+
+const (
+	tokenTerm tokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type queryToken struct {
+	kind tokenKind
+	text string
+	node *queryNode
+}
+
+// lexQuery splits s into tokens. A minus sign only negates when it starts a
+// term, so words like e-commerce stay whole.
+func lexQuery(s string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r) || r == ',':
+			i++
+		case r == '(':
+			tokens = append(tokens, queryToken{kind: tokenOpen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, queryToken{kind: tokenClose, text: ")"})
+			i++
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && runes[i+1] != ',':
+			tokens = append(tokens, queryToken{kind: tokenNot, text: "-"})
+			i++
+		default:
+			node := &queryNode{op: opTerm}
+			start := i
+			if r != '"' {
+				for i < len(runes) && !isQueryDelimiter(runes[i]) {
+					if runes[i] == ':' && queryFields[strings.ToLower(string(runes[start:i]))] {
+						node.field = strings.ToLower(string(runes[start:i]))
+						i++
+						start = i
+						break
+					}
+					i++
+				}
+			}
+			if i < len(runes) && runes[i] == '"' {
+				end := i + 1
+				for end < len(runes) && runes[end] != '"' {
+					end++
+				}
+				if end == len(runes) {
+					return nil, fmt.Errorf("unterminated phrase")
+				}
+				node.text = strings.Join(strings.Fields(string(runes[i+1:end])), " ")
+				node.phrase = true
+				i = end + 1
+			} else {
+				for i < len(runes) && !isQueryDelimiter(runes[i]) {
+					i++
+				}
+				node.text = string(runes[start:i])
+			}
+			if node.text == "" {
+				if node.field != "" {
+					return nil, fmt.Errorf("%s: without value", node.field)
+				}
+				continue
+			}
+
+			token := queryToken{kind: tokenTerm, text: node.text, node: node}
+			if node.field == "" && !node.phrase {
+				switch node.text {
+				case "AND":
+					token = queryToken{kind: tokenAnd, text: "AND"}
+				case "OR":
+					token = queryToken{kind: tokenOr, text: "OR"}
+				case "NOT":
+					token = queryToken{kind: tokenNot, text: "NOT"}
+				}
+			}
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func isQueryDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || r == ',' || r == '(' || r == ')' || r == '"'
+}
+
+// queryParser is a recursive descent parser over the tokens of a query, with
+// NOT binding tighter than AND and AND binding tighter than OR.
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *queryParser) parseOr() (*queryNode, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	children := []*queryNode{first}
+	for {
+		token, ok := p.peek()
+		if !ok || token.kind != tokenOr {
+			break
+		}
+		p.pos++
+		next, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, next)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return &queryNode{op: opOr, children: children}, nil
+}
+
+func (p *queryParser) parseAnd() (*queryNode, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	children := []*queryNode{first}
+	explicit := false
+	for {
+		token, ok := p.peek()
+		if !ok || token.kind == tokenOr || token.kind == tokenClose {
+			break
+		}
+		if token.kind == tokenAnd {
+			explicit = true
+			p.pos++
+		}
+		next, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, next)
+	}
+	return newAndNode(children, explicit), nil
+}
+
+func (p *queryParser) parseUnary() (*queryNode, error) {
+	token, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+	switch token.kind {
+	case tokenNot:
+		p.pos++
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &queryNode{op: opNot, children: []*queryNode{child}}, nil
+	case tokenOpen:
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if token, ok := p.peek(); !ok || token.kind != tokenClose {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return node, nil
+	case tokenTerm:
+		p.pos++
+		return token.node, nil
+	}
+	return nil, fmt.Errorf("unexpected %s", token.text)
+}
@@ -0,0 +1,117 @@
+package linkedin
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustParseQuery(t *testing.T, s string) *Query {
+	t.Helper()
+	q, err := ParseQuery(s)
+	if err != nil {
+		t.Fatalf("Error parsing query %q: %v", s, err)
+	}
+	return q
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedKeywords string
+		expectedTerms    string
+		expectedPosted   Recency
+	}{
+		{"Software Engineer", "Software Engineer", "Software Engineer", RecencyAny},
+		{"golang,kubernetes", "golang kubernetes", "golang kubernetes", RecencyAny},
+		{
+			`"site reliability" AND (Go OR Rust) -intern`,
+			`"site reliability" AND (Go OR Rust) AND NOT intern`,
+			`"site reliability" (Go OR Rust) -intern`,
+			RecencyAny,
+		},
+		{"a b OR c", "(a b) OR c", "(a b) OR c", RecencyAny},
+		{"NOT (junior OR intern) e-commerce", "NOT (junior OR intern) e-commerce", "-junior -intern e-commerce", RecencyAny},
+		{"golang NOT (junior intern)", "golang NOT (junior intern)", "golang (-junior OR -intern)", RecencyAny},
+		{"NOT (junior (intern OR trainee))", "NOT (junior (intern OR trainee))", "-junior OR (-intern -trainee)", RecencyAny},
+		{`intitle:"platform engineer" site:linkedin.com/in`, `"platform engineer"`, `intitle:"platform engineer" site:linkedin.com/in`, RecencyAny},
+		{"devops posted:week", "devops", "devops", RecencyWeek},
+		{"go or rust", "go or rust", "go or rust", RecencyAny},
+		{"", "", "", RecencyAny},
+	}
+
+	for _, tt := range tests {
+		q := mustParseQuery(t, tt.input)
+		if keywords := q.Keywords(); keywords != tt.expectedKeywords {
+			t.Errorf("Expected keywords %q for %q, but got %q", tt.expectedKeywords, tt.input, keywords)
+		}
+		if terms := q.SearchTerms(); terms != tt.expectedTerms {
+			t.Errorf("Expected search terms %q for %q, but got %q", tt.expectedTerms, tt.input, terms)
+		}
+		if q.Posted() != tt.expectedPosted {
+			t.Errorf("Expected recency %q for %q, but got %q", tt.expectedPosted, tt.input, q.Posted())
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []string{
+		`"unterminated`,
+		"(go OR rust",
+		"go OR",
+		"go)",
+		"intitle:",
+		"posted:decade",
+		"go OR posted:week",
+	}
+
+	for _, input := range tests {
+		if _, err := ParseQuery(input); err == nil {
+			t.Errorf("Expected an error for %q, but got none", input)
+		}
+	}
+}
+
+func TestParseKeywords(t *testing.T) {
+	q, err := ParseKeywords([]string{"Go OR Rust", `"site reliability"`, "posted:day"})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if q.Keywords() != `(Go OR Rust) "site reliability"` {
+		t.Errorf("Expected keywords %q, but got %q", `(Go OR Rust) "site reliability"`, q.Keywords())
+	}
+	site := q.withSite(linkedInCompanySite)
+	if site.String() != `site:linkedin.com/company (Go OR Rust) "site reliability" posted:day` {
+		t.Errorf("Expected the query restricted to companies, but got %q", site.String())
+	}
+}
+
+func TestSearchURLRecency(t *testing.T) {
+	q := mustParseQuery(t, "devops posted:month")
+	now := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		searchURL string
+		param     string
+		expected  string
+	}{
+		{"google", googleSearchURL(q), "tbs", "qdr:m"},
+		{"bing", bingSearchURL(q), "filters", `ex1:"ez3"`},
+		{"duckduckgo", duckDuckGoSearchURL(q), "df", "m"},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.searchURL)
+		if value := u.Query().Get(tt.param); value != tt.expected {
+			t.Errorf("Expected %s=%s for %s, but got %q", tt.param, tt.expected, tt.name, value)
+		}
+		if !strings.Contains(u.Query().Get("q"), "devops") {
+			t.Errorf("Expected the query in the %s URL, but got %s", tt.name, tt.searchURL)
+		}
+	}
+
+	if filter := bingRecencyFilter(RecencyYear, now); filter != `ex1:"ez5_19266_19631"` {
+		t.Errorf("Expected a Bing range of a year, but got %s", filter)
+	}
+}
@@ -0,0 +1,177 @@
+package linkedin
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// maxBackoffFactor caps how much slower than its base interval a host can get.
+	maxBackoffFactor = 64
+	// backoffMultiplier is applied to the interval of a host on every block signal.
+	backoffMultiplier = 2
+	// recoveryDivisor is applied to the interval of a host on every success.
+	recoveryDivisor = 1.1
+	// minBackoffInterval is the interval backoff starts from for a host that
+	// is not paced at all.
+	minBackoffInterval = 100 * time.Millisecond
+)
+
+// HostLimiter paces requests with a separate token bucket per host, so
+// linkedin.com and the search engines each get their own budget. The interval
+// of a host grows multiplicatively when it starts blocking and shrinks slowly
+// again after successful requests.
+type HostLimiter struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	burst     int
+	overrides map[string]hostRate
+	hosts     map[string]*hostBucket
+}
+
+type hostRate struct {
+	interval time.Duration
+	burst    int
+}
+
+type hostBucket struct {
+	limiter  *rate.Limiter
+	interval time.Duration
+	factor   float64
+}
+
+// NewHostLimiter creates a HostLimiter allowing one request per interval per
+// host, with bursts of up to burst requests. An interval of 0 disables pacing
+// until a host starts blocking.
+func NewHostLimiter(interval time.Duration, burst int) *HostLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &HostLimiter{
+		interval:  interval,
+		burst:     burst,
+		overrides: make(map[string]hostRate),
+		hosts:     make(map[string]*hostBucket),
+	}
+}
+
+// SetHostRate overrides the interval and burst of a single host.
+func (l *HostLimiter) SetHostRate(host string, interval time.Duration, burst int) {
+	if burst < 1 {
+		burst = 1
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := hostKey(host)
+	l.overrides[key] = hostRate{interval: interval, burst: burst}
+	delete(l.hosts, key)
+}
+
+// Wait blocks until a request to host is allowed, or until ctx is done.
+func (l *HostLimiter) Wait(ctx context.Context, host string) error {
+	return l.bucket(host).limiter.Wait(ctx)
+}
+
+// Backoff slows down host after a block signal such as a 429 or an auth wall.
+func (l *HostLimiter) Backoff(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.bucketLocked(host)
+	b.factor *= backoffMultiplier
+	if b.factor > maxBackoffFactor {
+		b.factor = maxBackoffFactor
+	}
+	b.apply()
+}
+
+// Recover speeds host up again after a successful request.
+func (l *HostLimiter) Recover(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.bucketLocked(host)
+	if b.factor == 1 {
+		return
+	}
+	b.factor /= recoveryDivisor
+	if b.factor < 1 {
+		b.factor = 1
+	}
+	b.apply()
+}
+
+// Interval returns the current interval between two requests to host.
+func (l *HostLimiter) Interval(host string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.bucketLocked(host).current()
+}
+
+func (l *HostLimiter) bucket(host string) *hostBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.bucketLocked(host)
+}
+
+func (l *HostLimiter) bucketLocked(host string) *hostBucket {
+	key := hostKey(host)
+	if b, ok := l.hosts[key]; ok {
+		return b
+	}
+
+	hr := hostRate{interval: l.interval, burst: l.burst}
+	if override, ok := l.overrides[key]; ok {
+		hr = override
+	}
+	b := &hostBucket{
+		limiter:  rate.NewLimiter(rate.Inf, hr.burst),
+		interval: hr.interval,
+		factor:   1,
+	}
+	b.apply()
+	l.hosts[key] = b
+	return b
+}
+
+// current returns the interval of the bucket with its backoff applied. A
+// bucket without an interval backs off from minBackoffInterval.
+func (b *hostBucket) current() time.Duration {
+	interval := b.interval
+	if interval <= 0 {
+		if b.factor == 1 {
+			return 0
+		}
+		interval = minBackoffInterval
+	}
+	return time.Duration(float64(interval) * b.factor)
+}
+
+func (b *hostBucket) apply() {
+	interval := b.current()
+	if interval <= 0 {
+		b.limiter.SetLimit(rate.Inf)
+		return
+	}
+	b.limiter.SetLimit(rate.Every(interval))
+}
+
+// hostKey groups hosts by registrable domain, so www.linkedin.com and
+// be.linkedin.com share one budget.
+func hostKey(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return domain
+	}
+	return host
+}
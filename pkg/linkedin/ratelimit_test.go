@@ -0,0 +1,104 @@
+package linkedin
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHostKey(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"www.linkedin.com", "linkedin.com"},
+		{"be.linkedin.com", "linkedin.com"},
+		{"www.google.co.uk", "google.co.uk"},
+		{"127.0.0.1:8080", "127.0.0.1"},
+		{"localhost", "localhost"},
+	}
+
+	for _, test := range tests {
+		result := hostKey(test.input)
+		if result != test.expected {
+			t.Errorf("For input %s, expected %s, but got %s", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestHostLimiterBackoffAndRecover(t *testing.T) {
+	l := NewHostLimiter(100*time.Millisecond, 1)
+
+	l.Backoff("www.linkedin.com")
+	l.Backoff("www.linkedin.com")
+	if got := l.Interval("www.linkedin.com"); got != 400*time.Millisecond {
+		t.Errorf("Expected interval of 400ms after two backoffs, but got %v", got)
+	}
+	if got := l.Interval("www.google.com"); got != 100*time.Millisecond {
+		t.Errorf("Expected google.com to keep its own 100ms budget, but got %v", got)
+	}
+
+	for i := 0; i < 100; i++ {
+		l.Recover("www.linkedin.com")
+	}
+	if got := l.Interval("www.linkedin.com"); got != 100*time.Millisecond {
+		t.Errorf("Expected interval to recover to 100ms, but got %v", got)
+	}
+
+	for i := 0; i < 100; i++ {
+		l.Backoff("www.linkedin.com")
+	}
+	if got := l.Interval("www.linkedin.com"); got != maxBackoffFactor*100*time.Millisecond {
+		t.Errorf("Expected interval to be capped at %v, but got %v", maxBackoffFactor*100*time.Millisecond, got)
+	}
+}
+
+func TestHostLimiterBackoffWithoutInterval(t *testing.T) {
+	l := NewHostLimiter(0, 1)
+	if got := l.Interval("www.linkedin.com"); got != 0 {
+		t.Errorf("Expected no interval before a backoff, but got %v", got)
+	}
+
+	l.Backoff("www.linkedin.com")
+	if got := l.Interval("www.linkedin.com"); got != backoffMultiplier*minBackoffInterval {
+		t.Errorf("Expected interval of %v after a backoff, but got %v", backoffMultiplier*minBackoffInterval, got)
+	}
+	if err := l.Wait(context.Background(), "www.linkedin.com"); err != nil {
+		t.Fatalf("Expected first request to pass the burst, but got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "www.linkedin.com"); err == nil {
+		t.Errorf("Expected second request to be paced after a backoff")
+	}
+
+	for i := 0; i < 100; i++ {
+		l.Recover("www.linkedin.com")
+	}
+	if got := l.Interval("www.linkedin.com"); got != 0 {
+		t.Errorf("Expected interval to recover to none, but got %v", got)
+	}
+}
+
+func TestHostLimiterWait(t *testing.T) {
+	l := NewHostLimiter(0, 1)
+	for i := 0; i < 10; i++ {
+		if err := l.Wait(context.Background(), "www.linkedin.com"); err != nil {
+			t.Fatalf("Expected no wait with a zero interval, but got %v", err)
+		}
+	}
+
+	l = NewHostLimiter(time.Hour, 1)
+	l.SetHostRate("www.google.com", 0, 1)
+	if err := l.Wait(context.Background(), "www.linkedin.com"); err != nil {
+		t.Fatalf("Expected first request to pass the burst, but got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "www.linkedin.com"); err == nil {
+		t.Errorf("Expected second request to exceed the deadline")
+	}
+	if err := l.Wait(ctx, "www.google.com"); err != nil {
+		t.Errorf("Expected overridden host to pass, but got %v", err)
+	}
+}
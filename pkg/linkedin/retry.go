@@ -0,0 +1,160 @@
+package linkedin
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBaseBackoff = time.Second
+	defaultMaxBackoff  = 30 * time.Second
+	// maxBackoffShift keeps base * 2^shift from overflowing.
+	maxBackoffShift = 30
+)
+
+// DefaultRetryStatuses are the status codes retried by default.
+var DefaultRetryStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+	statusLinkedInBlocked,
+}
+
+// RetryPolicy decides whether a failed attempt is retried and how long to wait
+// before the next one. Attempts are numbered from 1.
+type RetryPolicy interface {
+	Retry(attempt int, resp *http.Response, err error) (time.Duration, bool)
+}
+
+// ExponentialBackoff retries transport errors and the configured status codes
+// with a jittered exponential backoff. A Retry-After header takes precedence
+// over the computed backoff, but never exceeds Max.
+type ExponentialBackoff struct {
+	Base     time.Duration
+	Max      time.Duration
+	Statuses []int
+}
+
+// NewExponentialBackoff creates an ExponentialBackoff retrying the default
+// status codes with a backoff capped at max.
+func NewExponentialBackoff(max time.Duration) *ExponentialBackoff {
+	return &ExponentialBackoff{
+		Base:     defaultBaseBackoff,
+		Max:      max,
+		Statuses: DefaultRetryStatuses,
+	}
+}
+
+// Retry implements RetryPolicy.
+func (p *ExponentialBackoff) Retry(attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if err == nil && !p.retryStatus(resp.StatusCode) {
+		return 0, false
+	}
+	// A request missing from a replayed HAR file stays missing.
+	if errors.Is(err, ErrNotRecorded) {
+		return 0, false
+	}
+	// So does a request budget until the day or hour is over.
+	if errors.Is(err, ErrBudgetExhausted) {
+		return 0, false
+	}
+
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			return p.cap(wait), true
+		}
+	}
+
+	// Equal jitter: a random wait between half and all of base * 2^(attempt-1).
+	shift := attempt - 1
+	if shift > maxBackoffShift {
+		shift = maxBackoffShift
+	}
+	backoff := p.cap(p.Base << uint(shift))
+	if backoff <= 0 {
+		return 0, true
+	}
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(backoff-half)+1)), true
+}
+
+func (p *ExponentialBackoff) retryStatus(statusCode int) bool {
+	for _, s := range p.Statuses {
+		if s == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ExponentialBackoff) cap(d time.Duration) time.Duration {
+	if p.Max > 0 && d > p.Max {
+		return p.Max
+	}
+	return d
+}
+
+// parseRetryAfter parses a Retry-After header in either delay-seconds or
+// HTTP-date form.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// Attempt records the outcome of one try of a request.
+type Attempt struct {
+	Number     int
+	URL        string
+	StatusCode int
+	Err        error
+	Duration   time.Duration
+	Wait       time.Duration
+}
+
+func (a Attempt) String() string {
+	outcome := fmt.Sprintf("status %d", a.StatusCode)
+	if a.Err != nil {
+		outcome = a.Err.Error()
+	}
+	if a.Wait > 0 {
+		return fmt.Sprintf("attempt %d to %s: %s after %v, retrying in %v", a.Number, a.URL, outcome, a.Duration, a.Wait)
+	}
+	return fmt.Sprintf("attempt %d to %s: %s after %v", a.Number, a.URL, outcome, a.Duration)
+}
+
+// RetryError is returned when a request still fails after its last attempt.
+type RetryError struct {
+	Attempts []Attempt
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("failed after %d attempts: %v", len(e.Attempts), e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
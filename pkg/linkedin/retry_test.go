@@ -0,0 +1,117 @@
+package linkedin
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		input    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{"0", 0, true},
+		{"Sun, 01 Oct 2023 12:00:30 GMT", 30 * time.Second, true},
+		{"Sun, 01 Oct 2023 11:00:00 GMT", 0, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"", 0, false},
+	}
+
+	for _, test := range tests {
+		result, ok := parseRetryAfter(test.input, now)
+		if ok != test.ok || result != test.expected {
+			t.Errorf("For input %q, expected %v (%v), but got %v (%v)", test.input, test.expected, test.ok, result, ok)
+		}
+	}
+}
+
+func TestExponentialBackoffRetry(t *testing.T) {
+	p := NewExponentialBackoff(5 * time.Second)
+
+	if _, retry := p.Retry(1, &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}}, nil); retry {
+		t.Errorf("Expected no retry for status 404")
+	}
+	if _, retry := p.Retry(1, nil, errors.New("connection reset")); !retry {
+		t.Errorf("Expected retry for transport error")
+	}
+
+	for attempt := 1; attempt <= 40; attempt++ {
+		wait, retry := p.Retry(attempt, &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}, nil)
+		if !retry {
+			t.Fatalf("Expected retry for status 503 on attempt %d", attempt)
+		}
+		if wait < 0 || wait > 5*time.Second {
+			t.Errorf("Expected wait between 0 and 5s on attempt %d, but got %v", attempt, wait)
+		}
+	}
+
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"60"}}}
+	if wait, _ := p.Retry(1, resp, nil); wait != 5*time.Second {
+		t.Errorf("Expected Retry-After to be capped at 5s, but got %v", wait)
+	}
+	resp.Header.Set("Retry-After", "2")
+	if wait, _ := p.Retry(1, resp, nil); wait != 2*time.Second {
+		t.Errorf("Expected Retry-After of 2s, but got %v", wait)
+	}
+}
+
+func TestDoWithRetry(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("Expected body %q, but got %q", "payload", body)
+		}
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var attempts []Attempt
+	client := NewScrapeClient(WithAttemptHook(func(a Attempt) { attempts = append(attempts, a) }))
+
+	req, err := http.NewRequest("POST", server.URL, strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("Error creating HTTP request: %v", err)
+	}
+	resp, err := client.DoWithRetry(req, 3)
+	if err != nil {
+		t.Fatalf("Expected success on third attempt, but got %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, but got %d", resp.StatusCode)
+	}
+	if len(attempts) != 3 {
+		t.Fatalf("Expected 3 attempts, but got %d", len(attempts))
+	}
+	if attempts[0].StatusCode != http.StatusServiceUnavailable || attempts[2].StatusCode != http.StatusOK {
+		t.Errorf("Unexpected attempt log: %v", attempts)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	req, _ = http.NewRequest("POST", server.URL, strings.NewReader("payload"))
+	resp, err = client.DoWithRetry(req, 2)
+	if err != nil {
+		t.Fatalf("Expected last response to be returned, but got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 after running out of attempts, but got %d", resp.StatusCode)
+	}
+}
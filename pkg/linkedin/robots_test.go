@@ -0,0 +1,67 @@
+package linkedin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBundledRobotsPolicy(t *testing.T) {
+	tests := []struct {
+		agent    string
+		url      string
+		expected bool
+	}{
+		{"lictl", "https://www.linkedin.com/company/tetrate", false},
+		{"Googlebot", "https://www.linkedin.com/company/tetrate", true},
+		{"Googlebot", "https://www.linkedin.com/jobs-guest/jobs/api/seeMoreJobPostings/search?start=0", false},
+		{"lictl", "https://www.google.com/search?q=tetrate", true},
+	}
+
+	for _, test := range tests {
+		policy, err := BundledRobotsPolicy(test.agent)
+		if err != nil {
+			t.Fatalf("Error loading bundled robots.txt: %v", err)
+		}
+		u, _ := url.Parse(test.url)
+		result := policy.Allowed(u)
+		if result != test.expected {
+			t.Errorf("For agent %s and url %s, expected %v, but got %v", test.agent, test.url, test.expected, result)
+		}
+	}
+}
+
+func TestScrapeClientRobotsPolicy(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte(`<html><body><h1 class="top-card-layout__title">Tetrate</h1></body></html>`))
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	policy, err := NewRobotsPolicy(serverURL.Host, []byte("User-agent: *\nDisallow: /private\nCrawl-delay: 2\n"), DefaultRobotsAgent)
+	if err != nil {
+		t.Fatalf("Error parsing robots.txt: %v", err)
+	}
+	client := NewScrapeClient(WithRobotsPolicy(policy))
+
+	if interval := client.Limiter().Interval(serverURL.Host); interval != 2*time.Second {
+		t.Errorf("Expected crawl delay of 2s, but got %v", interval)
+	}
+
+	_, err = GetCompanyFromUrl(client, server.URL+"/private/company", false)
+	if !errors.Is(err, ErrDisallowed) {
+		t.Errorf("Expected ErrDisallowed, but got %v", err)
+	}
+	if hits != 0 {
+		t.Errorf("Expected no request for a disallowed URL, but got %d", hits)
+	}
+	if disallowed := client.Disallowed(); len(disallowed) != 1 || disallowed[0] != server.URL+"/private/company" {
+		t.Errorf("Expected the disallowed URL to be recorded, but got %v", disallowed)
+	}
+}
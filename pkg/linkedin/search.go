@@ -0,0 +1,220 @@
+package linkedin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	linkedInCompanySite = "linkedin.com/company"
+	linkedInPostSite    = "linkedin.com/posts"
+	linkedInPulseSite   = "linkedin.com/pulse"
+	linkedInUserSite    = "linkedin.com/in"
+
+	// searchMaxResults is the number of result URLs taken from a search
+	// engine per query.
+	searchMaxResults = 100
+)
+
+// SearchEngine finds LinkedIn pages through a web search engine.
+type SearchEngine interface {
+	// Name identifies the engine in hits, the cache and errors.
+	Name() string
+	// Search returns up to searchMaxResults hits of query, fetching the
+	// result pages through client. Only URL, Title and Snippet of the hits
+	// need to be set.
+	Search(ctx context.Context, client *ScrapeClient, query *Query) (SearchHits, error)
+}
+
+// SearchEngines are the built-in search engines, by name.
+var SearchEngines = map[string]SearchEngine{
+	"bing":       BingEngine{},
+	"duckduckgo": DuckDuckGoEngine{},
+	"google":     GoogleEngine{},
+}
+
+// SearchEngineNames returns the names of the built-in search engines.
+func SearchEngineNames() []string {
+	names := make([]string, 0, len(SearchEngines))
+	for name := range SearchEngines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// searchLinkedInURLs searches the LinkedIn pages under site matching keywords
+// with the search engines of the client. Keywords are parsed with
+// ParseKeywords.
+func searchLinkedInURLs(ctx context.Context, client *ScrapeClient, site string, keywords []string, debug bool) ([]string, error) {
+	query, err := ParseKeywords(keywords)
+	if err != nil {
+		return nil, err
+	}
+	return searchWith(ctx, client, client.searchEngines, query.withSite(site), debug)
+}
+
+// searchLinkedInHits is like searchLinkedInURLs, but returns the hits
+// themselves. Hits are not recorded in the checkpoint of the client.
+func searchLinkedInHits(ctx context.Context, client *ScrapeClient, site string, keywords []string, debug bool) (SearchHits, error) {
+	query, err := ParseKeywords(keywords)
+	if err != nil {
+		return nil, err
+	}
+	return searchHits(ctx, client, client.searchEngines, query.withSite(site), debug)
+}
+
+// searchWith returns the URLs of the hits of searchHits. The URLs are
+// recorded in the checkpoint of the client, if any.
+func searchWith(ctx context.Context, client *ScrapeClient, engines []SearchEngine, query *Query, debug bool) ([]string, error) {
+	// Resume with the results of the interrupted run, as the search engines
+	// may rank them differently by now.
+	key := searchCacheKey("search", query.String())
+	if client.checkpoint != nil {
+		if urls, ok := client.checkpoint.searchURLs(key); ok {
+			return urls, nil
+		}
+	}
+
+	hits, err := searchHits(ctx, client, engines, query, debug)
+	if err != nil {
+		return nil, err
+	}
+	urls := hits.URLs()
+
+	if client.checkpoint != nil {
+		if err := client.checkpoint.setSearchURLs(key, urls); err != nil {
+			log.Printf("failed to save checkpoint %s: %v", client.checkpoint.Path(), err)
+		}
+	}
+	return urls, nil
+}
+
+// searchHits searches query with each of engines in turn, until one of them
+// finds results. An engine that fails or finds nothing falls back to the
+// next one.
+func searchHits(ctx context.Context, client *ScrapeClient, engines []SearchEngine, query *Query, debug bool) (SearchHits, error) {
+	var errs []error
+	for i, engine := range engines {
+		hits, err := searchCached(ctx, client, engine, query)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", engine.Name(), err))
+			if debug && i < len(engines)-1 {
+				log.Printf("search engine %s failed, falling back to the next one: %v", engine.Name(), err)
+			}
+			continue
+		}
+		if len(hits) > 0 {
+			return hits, nil
+		}
+		if debug && i < len(engines)-1 {
+			log.Printf("search engine %s found nothing, falling back to the next one", engine.Name())
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return nil, nil
+}
+
+// searchCached runs engine.Search, serving the hits from the response cache
+// when one is configured, and ranks them.
+func searchCached(ctx context.Context, client *ScrapeClient, engine SearchEngine, query *Query) (SearchHits, error) {
+	var hits SearchHits
+	if client.cache == nil {
+		var err error
+		if hits, err = engine.Search(ctx, client, query); err != nil {
+			return nil, err
+		}
+	} else {
+		body, err := client.cache.fetch(searchCacheKey(engine.Name(), query.String()), EntitySearch, func() ([]byte, error) {
+			hits, err := engine.Search(ctx, client, query)
+			if err != nil {
+				return nil, err
+			}
+			return json.Marshal(hits)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(body, &hits); err != nil {
+			return nil, fmt.Errorf("failed to read cached search results: %w", err)
+		}
+	}
+
+	for i, hit := range hits {
+		hit.Rank = i + 1
+		hit.Engine = engine.Name()
+		hit.Query = query.String()
+	}
+	return hits, nil
+}
+
+// searchPages fetches the result pages of a search engine from first on,
+// collecting the hits parse finds until parse finds no next page or
+// searchMaxResults is reached. Hits with a URL seen before are dropped, and
+// paging stops at a page without new hits or a next page visited before, so
+// an engine serving the same page over and over is not fetched forever.
+func searchPages(ctx context.Context, client *ScrapeClient, first string, parse func(*goquery.Document, *url.URL) (SearchHits, string)) (SearchHits, error) {
+	var hits SearchHits
+	seen := make(map[string]bool)
+	visited := make(map[string]bool)
+	for next := first; next != "" && !visited[next] && len(hits) < searchMaxResults; {
+		visited[next] = true
+		req, err := newRequest(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+		// Result pages are not cached on their own, as the results of the
+		// whole query are. They bypass the circuit breaker and robots.txt of
+		// LinkedIn, and an engine that blocks falls back to the next one.
+		doc, _, err := client.fetchPage(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var pageHits SearchHits
+		pageHits, next = parse(doc, req.URL)
+		added := 0
+		for _, hit := range pageHits {
+			if !seen[hit.URL] {
+				seen[hit.URL] = true
+				hits = append(hits, hit)
+				added++
+			}
+		}
+		if added == 0 {
+			break
+		}
+	}
+	if len(hits) > searchMaxResults {
+		hits = hits[:searchMaxResults]
+	}
+	return hits, nil
+}
+
+// cleanText collapses the white space of the text of s.
+func cleanText(s *goquery.Selection) string {
+	return strings.Join(strings.Fields(s.Text()), " ")
+}
+
+// resolveURL returns ref resolved against base, or an empty string when ref
+// is not a valid URL.
+func resolveURL(base *url.URL, ref string) string {
+	resolved, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ""
+	}
+	return resolved.String()
+}
@@ -0,0 +1,224 @@
+package linkedin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestSearchEngineParsers(t *testing.T) {
+	tests := []struct {
+		fileName     string
+		pageURL      string
+		parse        func(*goquery.Document, *url.URL) (SearchHits, string)
+		expectedURLs []string
+		expectedNext string
+	}{
+		{
+			"google.html",
+			googleSearchURL(mustParseQuery(t, "site:linkedin.com/company tetrate")),
+			parseGoogleResults,
+			[]string{
+				"https://www.linkedin.com/company/tetrate",
+				"https://nl.linkedin.com/company/tetrate-io",
+				"https://www.linkedin.com/company/tetrate-labs",
+			},
+			"https://www.google.com/search?q=site:linkedin.com/company+tetrate&hl=en&num=100&start=100&sa=N",
+		},
+		{
+			"bing.html",
+			bingSearchURL(mustParseQuery(t, "site:linkedin.com/company tetrate")),
+			parseBingResults,
+			[]string{
+				"https://www.linkedin.com/company/tetrate",
+				"https://nl.linkedin.com/company/tetrate-io",
+				"https://www.linkedin.com/company/tetrate-labs",
+			},
+			"https://www.bing.com/search?q=site%3alinkedin.com%2fcompany+tetrate&setlang=en&count=50&first=51&FORM=PORE",
+		},
+		{
+			"duckduckgo.html",
+			duckDuckGoSearchURL(mustParseQuery(t, "site:linkedin.com/company tetrate")),
+			parseDuckDuckGoResults,
+			[]string{
+				"https://www.linkedin.com/company/tetrate",
+				"https://nl.linkedin.com/company/tetrate-io",
+				"https://www.linkedin.com/company/tetrate-labs",
+			},
+			"https://html.duckduckgo.com/html/?api=d.js&dc=11&kl=wt-wt&nextParams=&o=json&q=site%3Alinkedin.com%2Fcompany+tetrate&s=10&v=l&vqd=4-123456789012345678901234567890",
+		},
+	}
+
+	// Directory containing test HTML files
+	_, filename, _, _ := runtime.Caller(0)
+	basepath := filepath.Dir(filename)
+	testDir := filepath.Join(basepath, "../..", "testdata", "search")
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			file, err := os.Open(filepath.Join(testDir, tt.fileName))
+			if err != nil {
+				t.Fatalf("Error opening %s: %v", tt.fileName, err)
+			}
+			defer file.Close()
+			doc, err := goquery.NewDocumentFromReader(file)
+			if err != nil {
+				t.Fatalf("Error parsing %s: %v", tt.fileName, err)
+			}
+			pageURL, _ := url.Parse(tt.pageURL)
+
+			hits, next := tt.parse(doc, pageURL)
+			if urls := hits.URLs(); strings.Join(urls, ",") != strings.Join(tt.expectedURLs, ",") {
+				t.Errorf("Expected URLs %v for file %s, but got %v", tt.expectedURLs, tt.fileName, urls)
+			}
+			if len(hits) > 0 && hits[0].Title != "Tetrate | LinkedIn" {
+				t.Errorf("Expected title %q for file %s, but got %q", "Tetrate | LinkedIn", tt.fileName, hits[0].Title)
+			}
+			if len(hits) > 0 && !strings.HasPrefix(hits[0].Snippet, "Tetrate | 14,018 followers on LinkedIn.") {
+				t.Errorf("Expected the snippet of Tetrate for file %s, but got %q", tt.fileName, hits[0].Snippet)
+			}
+			if next != tt.expectedNext {
+				t.Errorf("Expected next page %s for file %s, but got %s", tt.expectedNext, tt.fileName, next)
+			}
+		})
+	}
+}
+
+// stubEngine is a SearchEngine with canned results.
+type stubEngine struct {
+	name  string
+	hits  SearchHits
+	err   error
+	calls int
+}
+
+func (e *stubEngine) Name() string {
+	return e.name
+}
+
+func (e *stubEngine) Search(ctx context.Context, client *ScrapeClient, query *Query) (SearchHits, error) {
+	e.calls++
+	return e.hits, e.err
+}
+
+func TestSearchEngineFallback(t *testing.T) {
+	blocked := &stubEngine{name: "blocked", err: &HTTPError{StatusCode: 429, Kind: ErrRateLimited}}
+	empty := &stubEngine{name: "empty"}
+	working := &stubEngine{name: "working", hits: SearchHits{{URL: "https://www.linkedin.com/company/tetrate"}}}
+	unused := &stubEngine{name: "unused", hits: SearchHits{{URL: "https://www.linkedin.com/company/other"}}}
+
+	client := NewScrapeClient(WithSearchEngines(blocked, empty, working, unused))
+	urls, err := searchLinkedInURLs(context.Background(), client, linkedInCompanySite, []string{"tetrate"}, false)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if len(urls) != 1 || urls[0] != "https://www.linkedin.com/company/tetrate" {
+		t.Errorf("Expected the results of the working engine, but got %v", urls)
+	}
+	if blocked.calls != 1 || empty.calls != 1 || working.calls != 1 || unused.calls != 0 {
+		t.Errorf("Expected engines to be tried in order until one finds results, but got calls %d, %d, %d, %d", blocked.calls, empty.calls, working.calls, unused.calls)
+	}
+
+	client = NewScrapeClient(WithSearchEngines(blocked, empty))
+	_, err = searchLinkedInURLs(context.Background(), client, linkedInCompanySite, []string{"tetrate"}, false)
+	if !errors.Is(err, ErrRateLimited) || !strings.Contains(err.Error(), "blocked:") {
+		t.Errorf("Expected the rate limit error of the blocked engine, but got %v", err)
+	}
+}
+
+func TestSearchHits(t *testing.T) {
+	engine := &stubEngine{name: "stub", hits: SearchHits{
+		{URL: "https://www.linkedin.com/company/tetrate", Title: "Tetrate | LinkedIn"},
+		{URL: "https://www.linkedin.com/company/istio", Title: "Istio | LinkedIn"},
+	}}
+	client := NewScrapeClient(WithSearchEngines(engine))
+
+	hits, err := SearchCompanyHitsOnline(client, []string{"service mesh"}, false)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("Expected 2 hits, but got %d", len(hits))
+	}
+	for i, hit := range hits {
+		if hit.Rank != i+1 || hit.Engine != "stub" || hit.Query != "site:linkedin.com/company service mesh" {
+			t.Errorf("Expected hit %d ranked and tagged with engine and query, but got %+v", i, hit)
+		}
+	}
+}
+
+func TestSearchHitUnmarshalURL(t *testing.T) {
+	var hits SearchHits
+	if err := json.Unmarshal([]byte(`["https://www.linkedin.com/company/tetrate",{"url":"https://www.linkedin.com/company/istio","title":"Istio"}]`), &hits); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if len(hits) != 2 || hits[0].URL != "https://www.linkedin.com/company/tetrate" || hits[1].Title != "Istio" {
+		t.Errorf("Expected a hit from a bare URL and one from an object, but got %+v %+v", hits[0], hits[1])
+	}
+}
+
+func TestSearchPagesBypassBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(1, 0)
+	client := NewScrapeClient(WithRetries(1), WithCircuitBreaker(breaker))
+	parse := func(doc *goquery.Document, base *url.URL) (SearchHits, string) {
+		return nil, ""
+	}
+	if _, err := searchPages(context.Background(), client, server.URL+"/search", parse); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, but got %v", err)
+	}
+	if breaker.Open() {
+		t.Errorf("Expected a blocked search engine to leave the LinkedIn breaker closed")
+	}
+}
+
+func TestSearchPagesStopsOnRepeatedPages(t *testing.T) {
+	tests := []struct {
+		name          string
+		next          func(page int) string
+		expectedCalls int
+	}{
+		// Every page links the next one, but serves the same hits.
+		{"same hits", func(page int) string { return fmt.Sprintf("/search?page=%d", page+1) }, 2},
+		// The next page is the page itself.
+		{"same next page", func(page int) string { return fmt.Sprintf("/search?page=%d", page) }, 1},
+	}
+
+	for _, tt := range tests {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			fmt.Fprintf(w, `<html><body><a class="hit" href="https://www.linkedin.com/company/tetrate">Tetrate</a><a class="next" href="%s">Next</a></body></html>`, tt.next(page))
+		}))
+
+		parse := func(doc *goquery.Document, base *url.URL) (SearchHits, string) {
+			hits := SearchHits{{URL: doc.Find("a.hit").AttrOr("href", "")}}
+			return hits, resolveURL(base, doc.Find("a.next").AttrOr("href", ""))
+		}
+		hits, err := searchPages(context.Background(), NewScrapeClient(), server.URL+"/search?page=0", parse)
+		server.Close()
+		if err != nil {
+			t.Fatalf("Expected no error for %s, but got %v", tt.name, err)
+		}
+		if len(hits) != 1 || calls != tt.expectedCalls {
+			t.Errorf("Expected 1 hit in %d calls for %s, but got %d hits in %d calls", tt.expectedCalls, tt.name, len(hits), calls)
+		}
+	}
+}
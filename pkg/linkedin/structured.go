@@ -0,0 +1,218 @@
+package linkedin
+
+import (
+	"encoding/json"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// FieldSource names the part of a page the value of a field was extracted
+// from.
+type FieldSource string
+
+const (
+	// SourceJSONLD is the schema.org JSON-LD embedded in the page.
+	SourceJSONLD FieldSource = "json-ld"
+	// SourceOpenGraph is the og: meta tags of the page.
+	SourceOpenGraph FieldSource = "opengraph"
+	// SourceCSS is the markup of the page, found through CSS selectors.
+	SourceCSS FieldSource = "css"
+	// SourceURL is the URL the page was fetched from.
+	SourceURL FieldSource = "url"
+)
+
+// FieldSources maps the JSON names of the fields of a record to the source
+// that filled them. Fields left empty have no source. The sources are only
+// written to JSON, to keep the CSV columns the same.
+type FieldSources map[string]FieldSource
+
+// sourced is a candidate value for a field along with its source.
+type sourced[T comparable] struct {
+	source FieldSource
+	value  T
+}
+
+func fromJSONLD[T comparable](value T) sourced[T] {
+	return sourced[T]{SourceJSONLD, value}
+}
+
+func fromOpenGraph[T comparable](value T) sourced[T] {
+	return sourced[T]{SourceOpenGraph, value}
+}
+
+func fromCSS[T comparable](value T) sourced[T] {
+	return sourced[T]{SourceCSS, value}
+}
+
+func fromURL[T comparable](value T) sourced[T] {
+	return sourced[T]{SourceURL, value}
+}
+
+// pick returns the first of values that is set, and records its source for
+// field in sources. Parsers list the structured data before the CSS
+// selectors, so a field only depends on the markup when the structured data
+// lacks it.
+func pick[T comparable](sources FieldSources, field string, values ...sourced[T]) T {
+	var zero T
+	for _, v := range values {
+		if v.value != zero {
+			sources[field] = v.source
+			return v.value
+		}
+	}
+	return zero
+}
+
+// structuredData holds the JSON-LD objects and the OpenGraph tags of a page.
+type structuredData struct {
+	objects   []ldObject
+	openGraph map[string]string
+}
+
+// ldObject is a JSON-LD object, like an Organization or a JobPosting.
+type ldObject map[string]any
+
+// parseStructuredData collects the JSON-LD objects, including those in an
+// @graph, and the OpenGraph tags of doc. Malformed JSON-LD is skipped, which
+// leaves its fields to the CSS selectors.
+func parseStructuredData(doc *goquery.Document) *structuredData {
+	data := &structuredData{openGraph: make(map[string]string)}
+	doc.Find("script[type='application/ld+json']").Each(func(i int, s *goquery.Selection) {
+		var v any
+		if err := json.Unmarshal([]byte(s.Text()), &v); err != nil {
+			return
+		}
+		data.objects = append(data.objects, flattenLD(v)...)
+	})
+	doc.Find("meta[property^='og:']").Each(func(i int, s *goquery.Selection) {
+		property := s.AttrOr("property", "")
+		if _, exists := data.openGraph[property]; !exists {
+			// LinkedIn escapes the content of the tags twice.
+			data.openGraph[property] = strings.TrimSpace(html.UnescapeString(s.AttrOr("content", "")))
+		}
+	})
+	return data
+}
+
+func flattenLD(v any) []ldObject {
+	switch v := v.(type) {
+	case []any:
+		var objects []ldObject
+		for _, item := range v {
+			objects = append(objects, flattenLD(item)...)
+		}
+		return objects
+	case map[string]any:
+		if graph, ok := v["@graph"]; ok {
+			return flattenLD(graph)
+		}
+		return []ldObject{v}
+	}
+	return nil
+}
+
+// object returns the first JSON-LD object of type typ, or nil when the page
+// has none. Reading the fields of a nil object gives empty values.
+func (d *structuredData) object(typ string) ldObject {
+	for _, o := range d.objects {
+		if o.str("@type") == typ {
+			return o
+		}
+	}
+	return nil
+}
+
+// og returns the content of the OpenGraph tag og:property.
+func (d *structuredData) og(property string) string {
+	return d.openGraph["og:"+property]
+}
+
+// value returns the value at path in o, following the first element of any
+// list on the way.
+func (o ldObject) value(path ...string) any {
+	var v any = map[string]any(o)
+	for _, key := range path {
+		if list, ok := v.([]any); ok && len(list) > 0 {
+			v = list[0]
+		}
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	if list, ok := v.([]any); ok && len(list) > 0 {
+		v = list[0]
+	}
+	return v
+}
+
+// str returns the string at path in o, with numbers formatted, or an empty
+// string when there is none.
+func (o ldObject) str(path ...string) string {
+	switch v := o.value(path...).(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// num returns the number at path in o, or 0 when there is none.
+func (o ldObject) num(path ...string) int {
+	if v, ok := o.value(path...).(float64); ok {
+		return int(v)
+	}
+	return 0
+}
+
+// date returns the date at path in o. Dates given in milliseconds since the
+// epoch are formatted as RFC 3339.
+func (o ldObject) date(path ...string) string {
+	if ms, ok := o.value(path...).(float64); ok {
+		return time.UnixMilli(int64(ms)).UTC().Format(time.RFC3339)
+	}
+	return o.str(path...)
+}
+
+// time returns the date at path in o, given in milliseconds since the epoch
+// or as RFC 3339, and whether there is one.
+func (o ldObject) time(path ...string) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, o.date(path...))
+	return t.UTC(), err == nil
+}
+
+// place returns the locality and region of the PostalAddress at path in o.
+func (o ldObject) place(path ...string) string {
+	var parts []string
+	for _, key := range []string{"addressLocality", "addressRegion"} {
+		if part := o.str(append(append([]string{}, path...), key)...); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// interactions returns the count of the InteractionCounter of o for action,
+// like "LikeAction".
+func (o ldObject) interactions(action string) int {
+	counters, ok := o["interactionStatistic"].([]any)
+	if !ok {
+		counters = []any{o["interactionStatistic"]}
+	}
+	for _, counter := range counters {
+		c, ok := counter.(map[string]any)
+		if !ok {
+			continue
+		}
+		if strings.HasSuffix(ldObject(c).str("interactionType"), "/"+action) {
+			return ldObject(c).num("userInteractionCount")
+		}
+	}
+	return 0
+}
@@ -0,0 +1,137 @@
+package linkedin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestParseStructuredData(t *testing.T) {
+	page := `<html><head>
+<meta property="og:title" content="Tetrate &amp;amp; Istio | LinkedIn">
+<script type="application/ld+json">{"@context":"http://schema.org","@graph":[{"@type":"Person","name":"Jane Doe","jobTitle":["Engineer"],"address":{"addressLocality":"Ghent","addressRegion":"Flanders"}},{"@type":"WebPage"}]}</script>
+<script type="application/ld+json">{"@type":"Article","datePublished":1694433606000,"interactionStatistic":[{"interactionType":"http://schema.org/LikeAction","userInteractionCount":4}]}</script>
+<script type="application/ld+json">{"@type": broken</script>
+</head><body></body></html>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("Error parsing page: %v", err)
+	}
+
+	data := parseStructuredData(doc)
+	if len(data.objects) != 3 {
+		t.Fatalf("Expected 3 JSON-LD objects, but got %d", len(data.objects))
+	}
+	person := data.object("Person")
+	if person.str("name") != "Jane Doe" || person.str("jobTitle") != "Engineer" || person.place("address") != "Ghent, Flanders" {
+		t.Errorf("Expected the person from the @graph, but got %v", person)
+	}
+	article := data.object("Article")
+	if article.date("datePublished") != "2023-09-11T12:00:06Z" {
+		t.Errorf("Expected the publish date in RFC 3339, but got %q", article.date("datePublished"))
+	}
+	if article.interactions("LikeAction") != 4 {
+		t.Errorf("Expected 4 likes, but got %d", article.interactions("LikeAction"))
+	}
+	if data.object("Organization").str("name") != "" {
+		t.Errorf("Expected no organization, but got %v", data.object("Organization"))
+	}
+	if data.og("title") != "Tetrate & Istio | LinkedIn" {
+		t.Errorf("Expected the unescaped OpenGraph title, but got %q", data.og("title"))
+	}
+}
+
+func TestPickFallsBackPerField(t *testing.T) {
+	sources := make(FieldSources)
+	name := pick(sources, "name", fromJSONLD(""), fromOpenGraph("Tetrate"), fromCSS("Tetrate | LinkedIn"))
+	count := pick(sources, "followerCount", fromJSONLD(0), fromCSS(14018))
+	empty := pick(sources, "website", fromJSONLD(""), fromCSS(""))
+
+	if name != "Tetrate" || count != 14018 || empty != "" {
+		t.Errorf("Expected the first value set, but got %q, %d and %q", name, count, empty)
+	}
+	if sources["name"] != SourceOpenGraph || sources["followerCount"] != SourceCSS {
+		t.Errorf("Expected the sources of the picked values, but got %v", sources)
+	}
+	if _, ok := sources["website"]; ok {
+		t.Errorf("Expected no source for an empty field, but got %v", sources)
+	}
+}
+
+func TestGetCompanyWithoutStructuredData(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	basepath := filepath.Dir(filename)
+	page, err := os.ReadFile(filepath.Join(basepath, "../..", "testdata", "company", "company-1.html"))
+	if err != nil {
+		t.Fatalf("Error reading company-1.html: %v", err)
+	}
+
+	// Pages without structured data still give the same company, taken from
+	// the markup instead.
+	stripped := strings.NewReplacer(
+		`application/ld+json`, `application/json`,
+		`property="og:`, `property="x-og:`,
+	).Replace(string(page))
+
+	for name, content := range map[string]string{"structured": string(page), "stripped": stripped} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(content))
+		}))
+		company, err := GetCompanyFromUrl(NewScrapeClient(), server.URL, false)
+		server.Close()
+		if err != nil {
+			t.Fatalf("Expected no error for the %s page, but got %v", name, err)
+		}
+
+		expectedSource := SourceJSONLD
+		if name == "stripped" {
+			expectedSource = SourceCSS
+		}
+		if company.Name != "BioSpace" || company.Sources["name"] != expectedSource {
+			t.Errorf("Expected name BioSpace from %s for the %s page, but got %q from %s", expectedSource, name, company.Name, company.Sources["name"])
+		}
+		if company.Headquarters != "West Des Moines, Iowa" || company.Sources["headquarters"] != expectedSource {
+			t.Errorf("Expected headquarters from %s for the %s page, but got %q from %s", expectedSource, name, company.Headquarters, company.Sources["headquarters"])
+		}
+		if company.FollowerCount != 64494 {
+			t.Errorf("Expected 64494 followers for the %s page, but got %d", name, company.FollowerCount)
+		}
+		if company.Sources["industry"] != SourceCSS {
+			t.Errorf("Expected the industry from the markup for the %s page, but got %s", name, company.Sources["industry"])
+		}
+	}
+}
+
+func TestGetPostWithoutArticle(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	basepath := filepath.Dir(filename)
+	page, err := os.ReadFile(filepath.Join(basepath, "../..", "testdata", "post", "post-0.html"))
+	if err != nil {
+		t.Fatalf("Error reading post-0.html: %v", err)
+	}
+
+	// A page without the article markup still gives the fields of its
+	// structured data.
+	stripped := strings.NewReplacer("<article", "<section", "</article>", "</section>").Replace(string(page))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(stripped))
+	}))
+	defer server.Close()
+
+	post, err := GetPostFromUrl(NewScrapeClient(), server.URL, false)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if post.Author == "" || post.Sources["author"] != SourceJSONLD {
+		t.Errorf("Expected the author from json-ld, but got %q from %s", post.Author, post.Sources["author"])
+	}
+	if post.PostLink == "" || post.ActivityURN != "" {
+		t.Errorf("Expected the post link but no activity URN, but got %q and %q", post.PostLink, post.ActivityURN)
+	}
+}
@@ -1,24 +1,22 @@
 package linkedin
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
-	"time"
-
-	"github.com/PuerkitoBio/goquery"
-	"github.com/corpix/uarand"
 )
 
 // User represents the structure of a LinkedIn user.
 type User struct {
-	ConnectionCount string `json:"connectionCount" csv:"connectionCount"`
-	FollowerCount   string `json:"followerCount"   csv:"followerCount"`
-	UserTitle       string `json:"userTitle"       csv:"userTitle"`
-	Location        string `json:"location"        csv:"location"`
-	Name            string `json:"name"            csv:"name"`
-	UserLink        string `json:"userLink"        csv:"userLink"`
+	ConnectionCount string       `json:"connectionCount"   csv:"connectionCount"`
+	FollowerCount   string       `json:"followerCount"     csv:"followerCount"`
+	UserTitle       string       `json:"userTitle"         csv:"userTitle"`
+	Location        string       `json:"location"          csv:"location"`
+	Name            string       `json:"name"              csv:"name"`
+	UserLink        string       `json:"userLink"          csv:"userLink"`
+	Sources         FieldSources `json:"sources,omitempty" csv:"-"`
 }
 
 func (u *User) CsvContent() string {
@@ -52,81 +50,74 @@ func (us Users) Get(i int) Serializable {
 	return Serializable(us[i])
 }
 
-func SearchUsersOnline(keywords []string, interval time.Duration, debug bool) (Users, error) {
-	var users []*User
-	urls, err := GoogleGetLinkedInUserURLs(keywords, interval, debug)
-	if err != nil {
-		return nil, fmt.Errorf("error fetching LinkedIn user URLs: %v", err)
-	}
+func SearchUsersOnline(client *ScrapeClient, keywords []string, debug bool) (Users, error) {
+	return SearchUsersOnlineContext(context.Background(), client, keywords, debug)
+}
 
-	var errs []string
-	for _, url := range urls {
-		user, err := GetUserFromUrl(url, debug)
-		if err != nil {
-			errs = append(errs, fmt.Sprintf("error fetching user from URL %s: %v", url, err))
-			continue
-		}
-		users = append(users, user)
-	}
+// SearchUsersOnlineContext is like SearchUsersOnline, but stops fetching when
+// ctx is done and returns the users fetched so far along with ctx.Err().
+func SearchUsersOnlineContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool) (Users, error) {
+	return collect(func(yield func(*User, error) bool) error {
+		return StreamUsersOnlineContext(ctx, client, keywords, debug, yield)
+	})
+}
+
+func StreamUsersOnline(client *ScrapeClient, keywords []string, debug bool, yield func(*User, error) bool) error {
+	return StreamUsersOnlineContext(context.Background(), client, keywords, debug, yield)
+}
 
-	if len(errs) > 0 {
-		return users, fmt.Errorf("encountered errors: %s", strings.Join(errs, "; "))
+// StreamUsersOnlineContext is like SearchUsersOnlineContext, but passes every
+// user to yield as soon as it is fetched, in search result order, instead of
+// returning them all at the end. Errors fetching a single user are passed to
+// yield as well. Returning false from yield stops the search.
+func StreamUsersOnlineContext(ctx context.Context, client *ScrapeClient, keywords []string, debug bool, yield func(*User, error) bool) error {
+	urls, err := searchLinkedInURLs(ctx, client, linkedInUserSite, keywords, debug)
+	if err != nil {
+		return fmt.Errorf("error fetching LinkedIn user URLs: %w", err)
 	}
 
-	return users, nil
+	return streamAll(ctx, client, urls, "user", func(ctx context.Context, url string) (*User, error) {
+		return GetUserFromUrlContext(ctx, client, url, debug)
+	}, yield)
+}
+
+func GetUserFromUrl(client *ScrapeClient, url string, debug bool) (*User, error) {
+	return GetUserFromUrlContext(context.Background(), client, url, debug)
 }
 
-func GetUserFromUrl(url string, debug bool) (*User, error) {
+// GetUserFromUrlContext is like GetUserFromUrl, but aborts the request when ctx
+// is done.
+func GetUserFromUrlContext(ctx context.Context, client *ScrapeClient, url string, debug bool) (*User, error) {
 	if debug {
 		fmt.Printf("going to fetch user from url %v", url)
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := newRequest(ctx, url)
 	if err != nil {
 		return &User{}, err
 	}
-	req.Header.Set("Accept-Encoding", "identity")
-	req.Header.Set("User-Agent", uarand.GetRandom())
 
-	user, err := getUserFromRequest(req, debug)
-	if err != nil {
-		if httpErr, ok := err.(*HTTPError); ok && httpErr.StatusCode == http.StatusTooManyRequests {
-			return &User{}, err
-		}
-		return &User{}, err
-	}
-	return user, nil
+	return getUserFromRequest(client, req, debug)
 }
 
-func getUserFromRequest(req *http.Request, debug bool) (*User, error) {
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return &User{}, fmt.Errorf("failed to fetch LinkedIn user: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check for non-2xx status codes
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return &User{}, &HTTPError{
-			StatusCode: resp.StatusCode,
-			Message:    fmt.Sprintf("received non-2xx response: %d %s", resp.StatusCode, resp.Status),
-		}
-	}
-
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+func getUserFromRequest(client *ScrapeClient, req *http.Request, debug bool) (*User, error) {
+	doc, err := client.fetchDocument(req)
 	if err != nil {
-		return &User{}, fmt.Errorf("failed to parse HTML: %w", err)
+		return &User{}, err
 	}
 
 	var user User
-	extractFollowersCount(strings.TrimSpace(doc.Find(".top-card-layout__first-subline").Text()))
-	connectionCount := strings.TrimSpace(doc.Find(".top-card-layout__first-subline span").Eq(0).Text())
-	followerCount := strings.TrimSpace(doc.Find(".top-card-layout__first-subline span").Eq(1).Text())
-	userTitle := strings.TrimSpace(doc.Find(".top-card-layout__headline").Text())
-	location := strings.TrimSpace(doc.Find(".top-card-layout__first-subline div").Text())
-	name := strings.TrimSpace(doc.Find(".top-card-layout__title").Text())
-	userLink := cleanURL(doc.Find("head link").AttrOr("href", ""))
+	data := parseStructuredData(doc)
+	person := data.object("Person")
+	sources := make(FieldSources)
+
+	connectionCount := pick(sources, "connectionCount", fromCSS(strings.TrimSpace(doc.Find(".top-card-layout__first-subline span").Eq(0).Text())))
+	followerCount := pick(sources, "followerCount", fromCSS(strings.TrimSpace(doc.Find(".top-card-layout__first-subline span").Eq(1).Text())))
+	// The jobTitle of the JSON-LD is only the first part of the headline.
+	userTitle := pick(sources, "userTitle", fromCSS(strings.TrimSpace(doc.Find(".top-card-layout__headline").Text())))
+	location := pick(sources, "location", fromJSONLD(person.str("address", "addressLocality")), fromCSS(strings.TrimSpace(doc.Find(".top-card-layout__first-subline div").Text())))
+	name := pick(sources, "name", fromJSONLD(person.str("name")), fromCSS(strings.TrimSpace(doc.Find(".top-card-layout__title").Text())))
+	userLink := pick(sources, "userLink", fromJSONLD(cleanURL(person.str("url"))), fromOpenGraph(cleanURL(data.og("url"))), fromCSS(cleanURL(doc.Find("head link").AttrOr("href", ""))))
 
 	user = User{
 		ConnectionCount: connectionCount,
@@ -135,6 +126,7 @@ func getUserFromRequest(req *http.Request, debug bool) (*User, error) {
 		Location:        location,
 		Name:            name,
 		UserLink:        userLink,
+		Sources:         sources,
 	}
 
 	// Print the user for testing
@@ -142,5 +134,8 @@ func getUserFromRequest(req *http.Request, debug bool) (*User, error) {
 		log.Printf("User: %+v", user)
 	}
 
+	if user.Name == "" {
+		return &user, &ParseError{URL: req.URL.String(), Entity: "user"}
+	}
 	return &user, nil
 }
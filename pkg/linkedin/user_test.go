@@ -185,7 +185,7 @@ func TestGetUserFromRequest(t *testing.T) {
 			if err != nil {
 				t.Fatalf("Error creating HTTP request: %v", err)
 			}
-			user, err := getUserFromRequest(req, false)
+			user, err := getUserFromRequest(NewScrapeClient(), req, false)
 			if err != nil {
 				t.Fatalf("Error in getUserFromRequest for file %s: %s", tt.fileName, err)
 			}
@@ -15,14 +15,16 @@ fi
 
 # Read the JSON file from the input parameter
 json_file="$1"
+tmp_dir=$(mktemp -d)
+trap 'rm -rf "${tmp_dir}"' EXIT
 
 # Iterate over the list of objects
 jq -c '.[]' "$json_file" | while read -r item; do
     companyLinkedInURL=$(echo "$item" | jq -r '.companyLinkedInURL')
     companyName=$(echo "$item" | jq -r '.companyName' | tr ' ' '_') # Replace spaces with underscores for filename
 
-    curl "${companyLinkedInURL}" \
-      -H "Accept-Encoding: identity" \
-      -H "User-Agent: Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/117.0.0.0 Safari/537.36" \
-      -o "company/${companyName}.html"
+    # Record the session, so the fixture is exactly what the client received
+    go run .. company get --url "${companyLinkedInURL}" --output "${tmp_dir}" --record "${tmp_dir}/${companyName}.har"
+    jq -r '.log.entries | map(select(.response.status == 200)) | last | .response.content.text' \
+      "${tmp_dir}/${companyName}.har" > "company/${companyName}.html"
 done